@@ -2,6 +2,8 @@ module github.com/svaruag/mocli
 
 go 1.22.2
 
-require golang.org/x/crypto v0.30.0
-
-require golang.org/x/sys v0.28.0 // indirect
+require (
+	golang.org/x/crypto v0.30.0
+	golang.org/x/sys v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
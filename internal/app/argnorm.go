@@ -1,6 +1,9 @@
 package app
 
-import "strings"
+import (
+	"flag"
+	"strings"
+)
 
 func normalizeOnePositionalArgs(args []string) []string {
 	if len(args) < 2 {
@@ -50,3 +53,39 @@ func normalizeTwoPositionalArgs(args []string) []string {
 	out = append(out, args[0], args[1])
 	return out
 }
+
+// parseFlagsAndIDs parses fs from args with flags and ids in any order, for
+// commands that accept a list of ids, and returns the ids. Flag parsing
+// resumes after each run of ids; everything after "--" is an id.
+func parseFlagsAndIDs(fs *flag.FlagSet, args []string) ([]string, error) {
+	ids := make([]string, 0, len(args))
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(ids, rest...), nil
+		}
+		if len(rest) == 0 {
+			return ids, nil
+		}
+		ids = append(ids, rest[0])
+		args = rest[1:]
+	}
+}
+
+// positionalIDs trims ids and requires at least one; noun names the id kind
+// in the error message.
+func positionalIDs(args []string, noun, usage string) ([]string, error) {
+	ids := make([]string, 0, len(args))
+	for _, a := range args {
+		if v := strings.TrimSpace(a); v != "" {
+			ids = append(ids, v)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, usageError(noun+" is required", usage)
+	}
+	return ids, nil
+}
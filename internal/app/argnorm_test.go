@@ -0,0 +1,47 @@
+package app
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseFlagsAndIDsAcceptsAnyOrder(t *testing.T) {
+	cases := map[string][]string{
+		"ids first":         {"id-1", "id-2", "--status", "complete", "--due", "2026-10-20T00:00:00Z"},
+		"flags first":       {"--status", "complete", "--due", "2026-10-20T00:00:00Z", "id-1", "id-2"},
+		"ids between flags": {"--status", "complete", "id-1", "id-2", "--due", "2026-10-20T00:00:00Z"},
+		"flag between ids":  {"id-1", "--status", "complete", "id-2", "--due=2026-10-20T00:00:00Z"},
+		"alternating":       {"id-1", "--status", "complete", "--due", "2026-10-20T00:00:00Z", "id-2"},
+	}
+	for name, args := range cases {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		status := fs.String("status", "", "")
+		due := fs.String("due", "", "")
+		ids, err := parseFlagsAndIDs(fs, args)
+		if err != nil {
+			t.Fatalf("%s: parseFlagsAndIDs returned error: %v", name, err)
+		}
+		if !reflect.DeepEqual(ids, []string{"id-1", "id-2"}) || *status != "complete" || *due != "2026-10-20T00:00:00Z" {
+			t.Fatalf("%s: got ids %q status %q due %q", name, ids, *status, *due)
+		}
+	}
+}
+
+func TestParseFlagsAndIDsStopsAtDoubleDash(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	force := fs.Bool("force", false, "")
+	ids, err := parseFlagsAndIDs(fs, []string{"id-1", "--", "-id-2", "--force"})
+	if err != nil {
+		t.Fatalf("parseFlagsAndIDs returned error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []string{"id-1", "-id-2", "--force"}) || *force {
+		t.Fatalf("got ids %q force %v", ids, *force)
+	}
+	if _, err := parseFlagsAndIDs(fs, []string{"id-1", "--unknown"}); err == nil {
+		t.Fatalf("expected unknown flag after an id to fail")
+	}
+}
@@ -92,8 +92,12 @@ func runAuthCredentials(rt *runtimeState, args []string) int {
 		return rt.failErr(err)
 	}
 	if cfg.DefaultClient == "" {
-		cfg.DefaultClient = client
-		_ = config.SaveAppConfig(cfg)
+		_, _ = config.UpdateAppConfig(func(cfg *config.AppConfig) error {
+			if cfg.DefaultClient == "" {
+				cfg.DefaultClient = client
+			}
+			return nil
+		})
 	}
 
 	return rt.writeJSON(map[string]any{
@@ -161,8 +165,10 @@ func runAuthAdd(rt *runtimeState, args []string) int {
 	device := fs.Bool("device", false, "Device code flow")
 	timeout := fs.Duration("timeout", 2*time.Minute, "Browser flow timeout")
 	forceConsent := fs.Bool("force-consent", false, "Force consent prompt")
+	var extra stringList
+	fs.Var(&extra, "scope", "Additional scope to consent to, e.g. Mail.ReadWrite.Shared (repeatable, comma-separated)")
 	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
-		return rt.failErr(usageError("invalid auth add flags", "Usage: mo auth add <email> [--device]"))
+		return rt.failErr(usageError("invalid auth add flags", "Usage: mo auth add <email> [--device] [--scope SCOPE]..."))
 	}
 	if fs.NArg() != 1 {
 		return rt.failErr(usageError("email is required", "Usage: mo auth add <email>"))
@@ -188,18 +194,26 @@ func runAuthAdd(rt *runtimeState, args []string) int {
 	if err != nil {
 		return rt.failErr(authRequiredError("could not open secrets backend", secretsBackendHint(err)))
 	}
+	scopes := append([]string{}, auth.DefaultScopes...)
+	for _, v := range extra {
+		for _, sc := range strings.Split(v, ",") {
+			if sc = strings.TrimSpace(sc); sc != "" && auth.MissingScopes(strings.Join(scopes, " "), []string{sc}) != nil {
+				scopes = append(scopes, sc)
+			}
+		}
+	}
 	if *device {
-		return runAuthAddDeviceFlow(rt, cfg, store, backendInfo, creds, client, email)
+		return runAuthAddDeviceFlow(rt, cfg, store, backendInfo, creds, client, email, scopes)
 	}
 
-	return runAuthAddBrowserFlow(rt, cfg, store, backendInfo, creds, client, email, *forceConsent, *timeout)
+	return runAuthAddBrowserFlow(rt, cfg, store, backendInfo, creds, client, email, *forceConsent, *timeout, scopes)
 }
 
-func runAuthAddDeviceFlow(rt *runtimeState, cfg config.AppConfig, store *secrets.Store, backendInfo secrets.BackendInfo, creds config.Credentials, client, email string) int {
+func runAuthAddDeviceFlow(rt *runtimeState, cfg config.AppConfig, store *secrets.Store, backendInfo secrets.BackendInfo, creds config.Credentials, client, email string, scopes []string) int {
 	startCtx, startCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer startCancel()
 
-	start, err := auth.StartDeviceCode(startCtx, creds, auth.DefaultScopes, rt.lookup)
+	start, err := auth.StartDeviceCode(startCtx, creds, scopes, rt.lookup)
 	if err != nil {
 		return rt.failErr(authRequiredError("device authorization setup failed", err.Error()))
 	}
@@ -217,14 +231,14 @@ func runAuthAddDeviceFlow(rt *runtimeState, cfg config.AppConfig, store *secrets
 	waitCtx, waitCancel := context.WithTimeout(context.Background(), waitFor)
 	defer waitCancel()
 
-	tok, err := auth.WaitForDeviceToken(waitCtx, creds, start, auth.DefaultScopes, rt.lookup)
+	tok, err := auth.WaitForDeviceToken(waitCtx, creds, start, scopes, rt.lookup)
 	if err != nil {
 		return rt.failErr(authRequiredError("device authorization failed", err.Error()))
 	}
-	return finalizeAuthAdd(rt, cfg, store, backendInfo, client, email, tok)
+	return finalizeAuthAdd(rt, store, backendInfo, client, email, tok)
 }
 
-func runAuthAddBrowserFlow(rt *runtimeState, cfg config.AppConfig, store *secrets.Store, backendInfo secrets.BackendInfo, creds config.Credentials, client, email string, forceConsent bool, timeout time.Duration) int {
+func runAuthAddBrowserFlow(rt *runtimeState, cfg config.AppConfig, store *secrets.Store, backendInfo secrets.BackendInfo, creds config.Credentials, client, email string, forceConsent bool, timeout time.Duration, scopes []string) int {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		return rt.failErr(err)
@@ -232,7 +246,7 @@ func runAuthAddBrowserFlow(rt *runtimeState, cfg config.AppConfig, store *secret
 	defer listener.Close()
 
 	redirectURI := "http://" + listener.Addr().String() + "/oauth2/callback"
-	session, err := auth.StartSession(creds, email, client, redirectURI, forceConsent, auth.DefaultScopes, rt.lookup)
+	session, err := auth.StartSession(creds, email, client, redirectURI, forceConsent, scopes, rt.lookup)
 	if err != nil {
 		return rt.failErr(err)
 	}
@@ -292,17 +306,17 @@ func runAuthAddBrowserFlow(rt *runtimeState, cfg config.AppConfig, store *secret
 		if result.State != session.State {
 			return rt.failErr(usageError("state mismatch", "Restart auth flow and use the latest redirect URL."))
 		}
-		tok, err := auth.ExchangeAuthCode(context.Background(), creds, result.Code, session.RedirectURI, session.Verifier, auth.DefaultScopes, rt.lookup)
+		tok, err := auth.ExchangeAuthCode(context.Background(), creds, result.Code, session.RedirectURI, session.Verifier, scopes, rt.lookup)
 		if err != nil {
 			return rt.failErr(authRequiredError("auth code exchange failed", err.Error()))
 		}
-		return finalizeAuthAdd(rt, cfg, store, backendInfo, client, email, tok)
+		return finalizeAuthAdd(rt, store, backendInfo, client, email, tok)
 	case <-time.After(timeout):
 		return rt.failErr(usageError("authorization timed out", "Re-run auth add and complete browser sign-in, or use --device."))
 	}
 }
 
-func finalizeAuthAdd(rt *runtimeState, cfg config.AppConfig, store *secrets.Store, backendInfo secrets.BackendInfo, client, requestedEmail string, tok auth.TokenResponse) int {
+func finalizeAuthAdd(rt *runtimeState, store *secrets.Store, backendInfo secrets.BackendInfo, client, requestedEmail string, tok auth.TokenResponse) int {
 	if strings.TrimSpace(tok.RefreshToken) == "" {
 		return rt.failErr(authRequiredError(
 			"token response did not include a refresh token",
@@ -321,15 +335,20 @@ func finalizeAuthAdd(rt *runtimeState, cfg config.AppConfig, store *secrets.Stor
 	}); err != nil {
 		return rt.failErr(err)
 	}
-
-	cfg.UpsertAccount(client, resolvedEmail)
-	if strings.TrimSpace(cfg.DefaultClient) == "" {
-		cfg.DefaultClient = client
-	}
-	if strings.TrimSpace(cfg.DefaultAccount) == "" {
-		cfg.DefaultAccount = resolvedEmail
+	if tok.ExpiresIn > 0 {
+		_ = store.PutAccessToken(client, resolvedEmail, accessTokenFromResponse(tok, time.Now().UTC()))
 	}
-	if err := config.SaveAppConfig(cfg); err != nil {
+
+	if _, err := config.UpdateAppConfig(func(cfg *config.AppConfig) error {
+		cfg.UpsertAccount(client, resolvedEmail)
+		if strings.TrimSpace(cfg.DefaultClient) == "" {
+			cfg.DefaultClient = client
+		}
+		if strings.TrimSpace(cfg.DefaultAccount) == "" {
+			cfg.DefaultAccount = resolvedEmail
+		}
+		return nil
+	}); err != nil {
 		return rt.failErr(err)
 	}
 
@@ -442,9 +461,14 @@ func runAuthRemove(rt *runtimeState, args []string) int {
 	if err := store.DeleteToken(client, email); err != nil && !errors.Is(err, secrets.ErrNotFound) {
 		return rt.failErr(err)
 	}
+	if err := store.DeleteAccessToken(client, email); err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return rt.failErr(err)
+	}
 
-	cfg.RemoveAccount(client, email)
-	if err := config.SaveAppConfig(cfg); err != nil {
+	if _, err := config.UpdateAppConfig(func(cfg *config.AppConfig) error {
+		cfg.RemoveAccount(client, email)
+		return nil
+	}); err != nil {
 		return rt.failErr(err)
 	}
 
@@ -0,0 +1,345 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const (
+	// batchMaxRequests is the Graph limit on sub-requests per $batch call.
+	batchMaxRequests = 20
+	batchMaxAttempts = 3
+)
+
+type batchRequest struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      any               `json:"body,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+type batchResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// err maps a failed sub-response to the same errors graphRequest returns.
+func (r batchResponse) err() error {
+	if r.Status >= 200 && r.Status < 300 {
+		return nil
+	}
+	var env graphErrorEnvelope
+	_ = json.Unmarshal(r.Body, &env)
+	msg := strings.TrimSpace(env.Error.Message)
+	if msg == "" {
+		msg = fmt.Sprintf("batch request %s failed with status %d", r.ID, r.Status)
+	}
+	return mapGraphError(r.Status, strings.TrimSpace(env.Error.Code), msg)
+}
+
+func (r batchResponse) retryAfter() time.Duration {
+	for k, v := range r.Headers {
+		if !strings.EqualFold(k, "Retry-After") {
+			continue
+		}
+		if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && secs > 0 {
+			d := time.Duration(secs) * time.Second
+			if d > maxRetryAfter {
+				return maxRetryAfter
+			}
+			return d
+		}
+	}
+	return 0
+}
+
+// graphBatch sends reqs through /$batch, at most batchMaxRequests per call.
+// Requests linked by dependsOn always travel in the same call. Sub-requests
+// throttled with 429 (or failing with a retryable status) are resent, along
+// with dependents that failed because of them. Responses are keyed by id.
+// Once ctx is done no further calls are made and the responses gathered so
+// far are returned, so callers report the rest as missing. A failed call
+// returns its error together with the responses of earlier calls, whose
+// changes were already applied.
+func (rt *runtimeState) graphBatch(ctx context.Context, id identityContext, reqs []batchRequest) (map[string]batchResponse, error) {
+	chunks, err := planBatchChunks(reqs)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]batchResponse, len(reqs))
+	for _, chunk := range chunks {
+		pending := chunk
+		for attempt := 1; len(pending) > 0; attempt++ {
+			if ctx.Err() != nil {
+				return out, nil
+			}
+			var resp struct {
+				Responses []batchResponse `json:"responses"`
+			}
+			if _, err := rt.graphRequestContext(ctx, id, http.MethodPost, rt.graphURL("/v1.0/$batch", nil), nil, map[string]any{"requests": pending}, &resp); err != nil {
+				if ctx.Err() != nil {
+					return out, nil
+				}
+				return out, err
+			}
+			for _, r := range resp.Responses {
+				out[r.ID] = r
+			}
+			if attempt == batchMaxAttempts {
+				break
+			}
+			var delay time.Duration
+			pending, delay = batchRetries(pending, out)
+			if len(pending) > 0 {
+				if delay == 0 {
+					delay = backoffDuration(attempt)
+				}
+				if sleepContext(ctx, delay) != nil {
+					return out, nil
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// batchRetries selects the requests to resend after a round: retryable
+// failures plus anything that failed its dependency on one of them. Already
+// satisfied dependencies are dropped from the resent requests.
+func batchRetries(sent []batchRequest, got map[string]batchResponse) ([]batchRequest, time.Duration) {
+	retry := map[string]bool{}
+	var delay time.Duration
+	for _, req := range sent {
+		r, ok := got[req.ID]
+		switch {
+		case !ok || shouldRetryStatus(r.Status):
+			retry[req.ID] = true
+			if d := r.retryAfter(); d > delay {
+				delay = d
+			}
+		case r.Status == http.StatusFailedDependency:
+			for _, dep := range req.DependsOn {
+				if retry[dep] {
+					retry[req.ID] = true
+				}
+			}
+		}
+	}
+	out := make([]batchRequest, 0, len(retry))
+	for _, req := range sent {
+		if !retry[req.ID] {
+			continue
+		}
+		deps := make([]string, 0, len(req.DependsOn))
+		for _, dep := range req.DependsOn {
+			if retry[dep] {
+				deps = append(deps, dep)
+			}
+		}
+		req.DependsOn = deps
+		out = append(out, req)
+	}
+	return out, delay
+}
+
+// planBatchChunks validates reqs and packs them, in order, into calls of at
+// most batchMaxRequests without splitting a dependsOn chain.
+func planBatchChunks(reqs []batchRequest) ([][]batchRequest, error) {
+	index := make(map[string]int, len(reqs))
+	parent := make([]int, len(reqs))
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	normalized := make([]batchRequest, len(reqs))
+	for i, req := range reqs {
+		req.ID = strings.TrimSpace(req.ID)
+		if req.ID == "" {
+			return nil, usageError("batch request id is required", "")
+		}
+		if _, dup := index[req.ID]; dup {
+			return nil, usageError(fmt.Sprintf("duplicate batch request id %q", req.ID), "")
+		}
+		req.URL = batchRelativeURL(req.URL)
+		if req.Body != nil {
+			if req.Headers == nil {
+				req.Headers = map[string]string{}
+			}
+			if _, ok := req.Headers["Content-Type"]; !ok {
+				req.Headers["Content-Type"] = "application/json"
+			}
+		}
+		index[req.ID] = i
+		parent[i] = i
+		for _, dep := range req.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, usageError(fmt.Sprintf("batch request %q depends on unknown or later request %q", req.ID, dep), "")
+			}
+			parent[find(i)] = find(j)
+		}
+		normalized[i] = req
+	}
+
+	groups := map[int][]batchRequest{}
+	order := make([]int, 0)
+	for i, req := range normalized {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], req)
+	}
+
+	chunks := make([][]batchRequest, 0)
+	var cur []batchRequest
+	for _, root := range order {
+		g := groups[root]
+		if len(g) > batchMaxRequests {
+			return nil, usageError(fmt.Sprintf("dependsOn chain of %d requests exceeds the batch limit of %d", len(g), batchMaxRequests), "")
+		}
+		if len(cur)+len(g) > batchMaxRequests {
+			chunks = append(chunks, cur)
+			cur = nil
+		}
+		cur = append(cur, g...)
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks, nil
+}
+
+// batchRelativeURL turns "/v1.0/me/..." paths used elsewhere into the
+// version-relative form $batch expects.
+func batchRelativeURL(path string) string {
+	path = strings.TrimSpace(path)
+	for _, prefix := range []string{"/v1.0/", "/beta/"} {
+		if strings.HasPrefix(path, prefix) {
+			return "/" + strings.TrimPrefix(path, prefix)
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// writeBatchResults runs one sub-request per item id and writes a per-item
+// result list. verb names the success flag ("deleted", "completed"); detail,
+// when set, adds fields from a successful response body. When any item fails
+// the exit code is that of the first failure, so scripts notice partial
+// failures while still getting the full report on stdout.
+func (rt *runtimeState) writeBatchResults(id identityContext, ids []string, verb string, build func(itemID string) batchRequest, detail func(map[string]any) map[string]any, extra map[string]any) int {
+	reqs := make([]batchRequest, 0, len(ids))
+	for i, itemID := range ids {
+		req := build(itemID)
+		req.ID = strconv.Itoa(i + 1)
+		reqs = append(reqs, req)
+	}
+	// Ctrl-C stops between batch calls; items without a response are
+	// reported as such.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// A failed batch call after earlier ones succeeded still reports the
+	// answered items; the rest carry the call's error.
+	got, err := rt.graphBatch(ctx, id, reqs)
+	if err != nil && len(got) == 0 {
+		return rt.failErr(err)
+	}
+	missing := transientError("no response for batch request", "Retry the command for the remaining ids.")
+	if ae, ok := err.(*appError); ok {
+		missing = &appError{Code: ae.Code, Message: "no response for batch request: " + ae.Message, Hint: "Retry the command for the remaining ids.", Exit: ae.Exit, Status: ae.Status}
+	} else if err != nil {
+		missing = transientError("no response for batch request: "+err.Error(), "Retry the command for the remaining ids.")
+	}
+
+	results := make([]map[string]any, 0, len(ids))
+	failed := 0
+	exit := exitcode.Success
+	for i, itemID := range ids {
+		itemErr := missing
+		r, ok := got[reqs[i].ID]
+		if ok {
+			itemErr = r.err()
+		}
+		if itemErr == nil {
+			entry := map[string]any{"id": itemID, verb: true}
+			if detail != nil {
+				var body map[string]any
+				_ = json.Unmarshal(r.Body, &body)
+				for k, v := range detail(body) {
+					entry[k] = v
+				}
+			}
+			results = append(results, entry)
+			continue
+		}
+		failed++
+		entry := map[string]any{"id": itemID, verb: false, "error": map[string]any{"message": itemErr.Error()}}
+		if ae, ok := itemErr.(*appError); ok {
+			entry["error"] = map[string]any{"code": ae.Code, "message": ae.Message}
+			if exit == exitcode.Success {
+				exit = ae.Exit
+			}
+		} else if exit == exitcode.Success {
+			exit = exitcode.TransientError
+		}
+		results = append(results, entry)
+	}
+
+	out := map[string]any{}
+	for k, v := range extra {
+		out[k] = v
+	}
+	out["results"] = results
+	out["succeeded"] = len(ids) - failed
+	out["failed"] = failed
+	if code := rt.writeJSON(out); code != exitcode.Success {
+		return code
+	}
+	return exit
+}
+
+// writeMutation applies the same change to one or more ids. A single id is
+// sent as a plain request and reported as {verb: true, id, ...}; several ids
+// go through graphBatch and are reported by writeBatchResults.
+func (rt *runtimeState) writeMutation(id identityContext, ids []string, verb string, build func(itemID string) batchRequest, detail func(map[string]any) map[string]any, extra map[string]any) int {
+	if len(ids) > 1 {
+		return rt.writeBatchResults(id, ids, verb, build, detail, extra)
+	}
+	req := build(ids[0])
+	var body map[string]any
+	if _, err := rt.graphRequest(id, req.Method, req.URL, nil, req.Body, &body); err != nil {
+		return rt.failErr(err)
+	}
+	out := map[string]any{verb: true, "id": ids[0]}
+	for k, v := range extra {
+		out[k] = v
+	}
+	if detail != nil {
+		for k, v := range detail(body) {
+			out[k] = v
+		}
+	}
+	return rt.writeJSON(out)
+}
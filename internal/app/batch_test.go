@@ -0,0 +1,221 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/svaruag/mocli/internal/auth"
+	"github.com/svaruag/mocli/internal/secrets"
+)
+
+// newTestGraphRuntime returns a runtime pointed at srv with a cached access
+// token, so Graph helpers run without a secrets store.
+func newTestGraphRuntime(srv *httptest.Server, out *bytes.Buffer) *runtimeState {
+	return &runtimeState{
+		stdout: out,
+		stderr: &bytes.Buffer{},
+		lookup: fakeEnv(map[string]string{"MO_GRAPH_BASE_URL": srv.URL}),
+		cachedToken: secrets.AccessToken{
+			AccessToken: "test-token",
+			Scope:       strings.Join(auth.DefaultScopes, " "),
+			ExpiresAt:   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+func TestPlanBatchChunksSplitsAtLimit(t *testing.T) {
+	reqs := make([]batchRequest, 45)
+	for i := range reqs {
+		reqs[i] = batchRequest{ID: fmt.Sprint(i + 1), Method: "DELETE", URL: "/v1.0/me/messages/x"}
+	}
+	chunks, err := planBatchChunks(reqs)
+	if err != nil {
+		t.Fatalf("planBatchChunks returned error: %v", err)
+	}
+	if len(chunks) != 3 || len(chunks[0]) != 20 || len(chunks[1]) != 20 || len(chunks[2]) != 5 {
+		t.Fatalf("unexpected chunk sizes: %d chunks", len(chunks))
+	}
+	if chunks[0][0].URL != "/me/messages/x" {
+		t.Fatalf("expected version prefix stripped, got %q", chunks[0][0].URL)
+	}
+}
+
+func TestPlanBatchChunksKeepsDependsOnChainTogether(t *testing.T) {
+	reqs := make([]batchRequest, 0, 22)
+	for i := 1; i <= 19; i++ {
+		reqs = append(reqs, batchRequest{ID: fmt.Sprint(i), Method: "GET", URL: "/me"})
+	}
+	reqs = append(reqs,
+		batchRequest{ID: "a", Method: "POST", URL: "/me/messages", Body: map[string]any{}},
+		batchRequest{ID: "b", Method: "POST", URL: "/me/messages/x/send", DependsOn: []string{"a"}},
+	)
+	chunks, err := planBatchChunks(reqs)
+	if err != nil {
+		t.Fatalf("planBatchChunks returned error: %v", err)
+	}
+	if len(chunks) != 2 || len(chunks[1]) != 2 || chunks[1][0].ID != "a" || chunks[1][1].ID != "b" {
+		t.Fatalf("expected chain a->b in its own chunk, got %d chunks", len(chunks))
+	}
+	if chunks[1][0].Headers["Content-Type"] != "application/json" {
+		t.Fatalf("expected JSON content type on request with body")
+	}
+
+	if _, err := planBatchChunks([]batchRequest{{ID: "1", DependsOn: []string{"2"}}, {ID: "2"}}); err == nil {
+		t.Fatalf("expected error for dependsOn on a later request")
+	}
+}
+
+func TestGraphBatchRetriesThrottledItems(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.0/$batch" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		var body struct {
+			Requests []batchRequest `json:"requests"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		calls++
+		call := calls
+		mu.Unlock()
+
+		responses := make([]map[string]any, 0, len(body.Requests))
+		for _, req := range body.Requests {
+			status := http.StatusNoContent
+			switch {
+			case call == 1 && req.ID == "2":
+				status = http.StatusTooManyRequests
+			case call == 1 && req.ID == "3":
+				status = http.StatusFailedDependency
+			case call == 2 && req.ID == "3" && len(req.DependsOn) != 1:
+				t.Errorf("expected retried dependent to keep dependsOn, got %v", req.DependsOn)
+			case req.ID == "4":
+				status = http.StatusNotFound
+			}
+			responses = append(responses, map[string]any{"id": req.ID, "status": status, "headers": map[string]string{"Retry-After": "0"}})
+		}
+		if call == 2 && len(body.Requests) != 2 {
+			t.Errorf("expected only throttled item and its dependent resent, got %d", len(body.Requests))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"responses": responses})
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	got, err := rt.graphBatch(context.Background(), identityContext{}, []batchRequest{
+		{ID: "1", Method: "DELETE", URL: "/v1.0/me/a"},
+		{ID: "2", Method: "DELETE", URL: "/v1.0/me/b"},
+		{ID: "3", Method: "DELETE", URL: "/v1.0/me/c", DependsOn: []string{"2"}},
+		{ID: "4", Method: "DELETE", URL: "/v1.0/me/d"},
+	})
+	if err != nil {
+		t.Fatalf("graphBatch returned error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 batch calls, got %d", calls)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if got[id].Status != http.StatusNoContent {
+			t.Fatalf("request %s: status %d", id, got[id].Status)
+		}
+	}
+	if err := got["4"].err(); err == nil {
+		t.Fatalf("expected not-found error for request 4")
+	}
+}
+
+func TestGraphBatchStopsRetryingWhenCancelled(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"responses":[` +
+			`{"id":"1","status":204},` +
+			`{"id":"2","status":429,"headers":{"Retry-After":"30"}}]}`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	started := time.Now()
+	got, err := rt.graphBatch(ctx, identityContext{}, []batchRequest{
+		{ID: "1", Method: "DELETE", URL: "/v1.0/me/a"},
+		{ID: "2", Method: "DELETE", URL: "/v1.0/me/b"},
+	})
+	if err != nil {
+		t.Fatalf("graphBatch returned error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second || calls != 1 {
+		t.Fatalf("expected one call and a prompt stop, got %d calls in %s", calls, elapsed)
+	}
+	if got["1"].Status != http.StatusNoContent || got["2"].Status != http.StatusTooManyRequests {
+		t.Fatalf("expected the responses gathered so far, got %v", got)
+	}
+}
+
+func TestWriteBatchResultsKeepsEarlierChunksWhenACallFails(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"BadRequest","message":"batch rejected"}}`))
+			return
+		}
+		var body struct {
+			Requests []batchRequest `json:"requests"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		responses := make([]map[string]any, 0, len(body.Requests))
+		for _, req := range body.Requests {
+			responses = append(responses, map[string]any{"id": req.ID, "status": http.StatusNoContent})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"responses": responses})
+	}))
+	defer srv.Close()
+
+	ids := make([]string, batchMaxRequests+2)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("task-%d", i+1)
+	}
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	code := rt.writeBatchResults(identityContext{}, ids, "deleted", func(taskID string) batchRequest {
+		return batchRequest{Method: http.MethodDelete, URL: "/v1.0/me/todo/lists/l/tasks/" + taskID}
+	}, nil, nil)
+	if code == 0 {
+		t.Fatalf("expected a failing exit code")
+	}
+	var got struct {
+		Results []struct {
+			ID      string         `json:"id"`
+			Deleted bool           `json:"deleted"`
+			Error   map[string]any `json:"error"`
+		} `json:"results"`
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid output %q: %v", out.String(), err)
+	}
+	if got.Succeeded != batchMaxRequests || got.Failed != 2 || len(got.Results) != len(ids) {
+		t.Fatalf("expected %d succeeded and 2 failed, got %+v", batchMaxRequests, got)
+	}
+	last := got.Results[len(ids)-1]
+	if last.Deleted || last.Error == nil {
+		t.Fatalf("expected unanswered item to carry an error, got %+v", last)
+	}
+}
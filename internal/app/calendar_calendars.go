@@ -0,0 +1,195 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const calendarSelect = "id,name,color,hexColor,isDefaultCalendar,canEdit,canShare,owner"
+
+// calendarColors are the values Graph accepts for a calendar's color.
+var calendarColors = []string{"auto", "lightBlue", "lightGreen", "lightOrange", "lightGray", "lightYellow", "lightTeal", "lightPink", "lightBrown", "lightRed"}
+
+// resolveCalendar turns a --calendar value into the base path that events
+// and calendarView hang off. No value keeps /me, the default calendar;
+// "default" is the same calendar by its own path; an email address is that
+// user's default calendar; anything else is a calendar name from
+// /me/calendars or, when no name matches and it looks like one, a calendar id
+// ("id:" forces one and skips the lookup). Another user's calendar needs the
+// shared calendar scope, which is added to the returned identity.
+func (rt *runtimeState) resolveCalendar(id identityContext, ref string, write bool) (identityContext, string, error) {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case ref == "":
+		return id, "/v1.0/me", nil
+	case strings.EqualFold(ref, "default"):
+		return id, "/v1.0/me/calendar", nil
+	case strings.HasPrefix(ref, "id:"):
+		return id, calendarPath(strings.TrimSpace(strings.TrimPrefix(ref, "id:"))), nil
+	case strings.Contains(ref, "@"):
+		if !strings.EqualFold(ref, id.Account) {
+			id.Scopes = append(id.Scopes, sharedCalendarScope(write))
+		}
+		return id, "/v1.0/users/" + url.PathEscape(ref) + "/calendar", nil
+	}
+
+	q := url.Values{}
+	q.Set("$select", "id,name")
+	matches := make([]string, 0, 1)
+	_, err := rt.listPages(id, "/v1.0/me/calendars", q, listOptions{All: true}, func(items []map[string]any) error {
+		for _, it := range items {
+			if strings.EqualFold(strings.TrimSpace(asString(it["name"])), ref) {
+				matches = append(matches, asString(it["id"]))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return id, "", err
+	}
+	switch len(matches) {
+	case 0:
+		if looksLikeGraphID(ref) {
+			return id, calendarPath(ref), nil
+		}
+		return id, "", notFoundError(fmt.Sprintf("calendar %q not found", ref), "Run 'mo calendar calendars' to list calendars.")
+	case 1:
+		return id, calendarPath(matches[0]), nil
+	default:
+		return id, "", usageError(fmt.Sprintf("%d calendars are named %q", len(matches), ref), "Pass the calendar id from 'mo calendar calendars' instead.")
+	}
+}
+
+func calendarPath(calendarID string) string {
+	return "/v1.0/me/calendars/" + url.PathEscape(calendarID)
+}
+
+func sharedCalendarScope(write bool) string {
+	if write {
+		return "Calendars.ReadWrite.Shared"
+	}
+	return "Calendars.Read.Shared"
+}
+
+func runCalendarCalendars(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) > 0 && isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("calendar"))
+		return exitcode.Success
+	}
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub = strings.ToLower(strings.TrimSpace(args[0]))
+		args = args[1:]
+	}
+	switch sub {
+	case "list":
+		return runCalendarCalendarsList(rt, id, args)
+	case "create":
+		return runCalendarCalendarsCreate(rt, id, args)
+	case "delete":
+		return runCalendarCalendarsDelete(rt, id, args)
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown calendar calendars subcommand %q", sub), "Run 'mo calendar help' for usage."))
+	}
+}
+
+func runCalendarCalendarsList(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo calendar calendars [list] [--user EMAIL]"
+	fs := flag.NewFlagSet("calendar calendars list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	user := fs.String("user", "", "List another user's calendars (needs access to them)")
+	if err := fs.Parse(args); err != nil {
+		return rt.failErr(usageError("invalid calendar calendars flags", usage))
+	}
+	if fs.NArg() != 0 {
+		return rt.failErr(usageError("calendar calendars list does not take positional arguments", usage))
+	}
+	path := "/v1.0/me/calendars"
+	if u := strings.TrimSpace(*user); u != "" && !strings.EqualFold(u, id.Account) {
+		id.Scopes = append(id.Scopes, sharedCalendarScope(false))
+		path = "/v1.0/users/" + url.PathEscape(u) + "/calendars"
+	}
+	q := url.Values{}
+	q.Set("$select", calendarSelect)
+	return rt.writeList(id, path, q, listOptions{All: true}, func(it map[string]any) string {
+		owner := emailAddressString(map[string]any{"emailAddress": it["owner"]})
+		return fmt.Sprintf("%s\t%s\t%s\t%v", asString(it["id"]), strings.ReplaceAll(asString(it["name"]), "\t", " "), owner, it["canEdit"])
+	}, nil)
+}
+
+func runCalendarCalendarsCreate(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo calendar calendars create <name> [--color COLOR]"
+	fs := flag.NewFlagSet("calendar calendars create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	color := fs.String("color", "", "auto, lightBlue, lightGreen, lightOrange, lightGray, lightYellow, lightTeal, lightPink, lightBrown or lightRed")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid calendar calendars create flags", usage))
+	}
+	if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+		return rt.failErr(usageError("calendar name is required", usage))
+	}
+	body := map[string]any{"name": strings.TrimSpace(fs.Arg(0))}
+	if c := strings.TrimSpace(*color); c != "" {
+		match := ""
+		for _, v := range calendarColors {
+			if strings.EqualFold(strings.ReplaceAll(c, " ", ""), v) {
+				match = v
+			}
+		}
+		if match == "" {
+			return rt.failErr(usageError(fmt.Sprintf("invalid --color %q", c), "Use one of: "+strings.Join(calendarColors, ", ")+"."))
+		}
+		body["color"] = match
+	}
+	var out map[string]any
+	if _, err := rt.graphRequest(id, http.MethodPost, "/v1.0/me/calendars", nil, body, &out); err != nil {
+		return rt.failErr(err)
+	}
+	delete(out, "@odata.context")
+	return rt.writeJSON(out)
+}
+
+func runCalendarCalendarsDelete(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo calendar calendars delete <calendar>"
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return rt.failErr(usageError("calendar is required", usage))
+	}
+	ref := strings.TrimSpace(args[0])
+	if strings.EqualFold(ref, "default") || strings.Contains(ref, "@") {
+		return rt.failErr(usageError("default calendars cannot be deleted", usage))
+	}
+	id, path, err := rt.resolveCalendar(id, ref, true)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	// Only a path to one calendar may be deleted, never /me or a user.
+	if !strings.Contains(path, "/calendars/") {
+		return rt.failErr(usageError("default calendars cannot be deleted", usage))
+	}
+	q := url.Values{}
+	q.Set("$select", "id,name,isDefaultCalendar")
+	var cal map[string]any
+	if _, err := rt.graphRequest(id, http.MethodGet, path, q, nil, &cal); err != nil {
+		return rt.failErr(err)
+	}
+	if cal["isDefaultCalendar"] == true {
+		return rt.failErr(usageError("default calendars cannot be deleted", "Pick another calendar from 'mo calendar calendars'."))
+	}
+	ok, err := confirmAction(rt, fmt.Sprintf("Delete calendar %q and all its events?", asString(cal["name"])))
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if !ok {
+		return rt.writeJSON(map[string]any{"deleted": false, "id": asString(cal["id"])})
+	}
+	if _, err := rt.graphRequest(id, http.MethodDelete, path, nil, nil, nil); err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{"deleted": true, "id": asString(cal["id"]), "name": asString(cal["name"])})
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveCalendar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value":[{"id":"c1","name":"Work"},{"id":"c2","name":"Team"},{"id":"c3","name":"team"},{"id":"c4","name":"ProjectArchive2024Quarterly_Reports-Final"}]}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	id := identityContext{Account: "me@contoso.com"}
+	for ref, want := range map[string]string{
+		"":        "/v1.0/me",
+		"default": "/v1.0/me/calendar",
+		"work":    "/v1.0/me/calendars/c1",
+		"id:c9":   "/v1.0/me/calendars/c9",
+		"ProjectArchive2024Quarterly_Reports-Final": "/v1.0/me/calendars/c4",
+		"AAMkAGI2TGuLAAA_calendar-id-that-is-long=": "/v1.0/me/calendars/AAMkAGI2TGuLAAA_calendar-id-that-is-long=",
+		"alice@contoso.com":                         "/v1.0/users/alice@contoso.com/calendar",
+	} {
+		_, got, err := rt.resolveCalendar(id, ref, false)
+		if err != nil || got != want {
+			t.Fatalf("resolveCalendar(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	if shared, _, _ := rt.resolveCalendar(id, "alice@contoso.com", true); len(shared.Scopes) != 1 || shared.Scopes[0] != "Calendars.ReadWrite.Shared" {
+		t.Fatalf("expected shared write scope, got %v", shared.Scopes)
+	}
+	if _, _, err := rt.resolveCalendar(id, "Team", false); err == nil {
+		t.Fatalf("expected ambiguous name to fail")
+	}
+	if _, _, err := rt.resolveCalendar(id, "Missing", false); err == nil {
+		t.Fatalf("expected unknown name to fail")
+	}
+}
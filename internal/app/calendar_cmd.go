@@ -1,9 +1,11 @@
 package app
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -44,6 +46,18 @@ func runCalendar(rt *runtimeState, args []string) int {
 			return rt.failErr(err)
 		}
 		return runCalendarDelete(rt, id, rest)
+	case "calendars":
+		id, err := rt.resolveIdentity()
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runCalendarCalendars(rt, id, rest)
+	case "instances":
+		id, err := rt.resolveIdentity()
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runCalendarInstances(rt, id, rest)
 	default:
 		return rt.failErr(usageError(fmt.Sprintf("unknown calendar subcommand %q", sub), "Run 'mo calendar help' for usage."))
 	}
@@ -52,18 +66,22 @@ func runCalendar(rt *runtimeState, args []string) int {
 func runCalendarList(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("calendar list", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	max := fs.Int("max", 50, "Max events")
-	page := fs.String("page", "", "Page token")
+	list := addListFlags(fs, 50)
 	from := fs.String("from", "", "Start RFC3339")
 	to := fs.String("to", "", "End RFC3339")
+	timeZone := fs.String("timezone", "", "Time zone for returned times (default: mailbox time zone)")
+	calendar := fs.String("calendar", "", "Calendar id, name, \"default\", or a user's email (default: your default calendar)")
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid calendar list flags", "Usage: mo calendar list [--from RFC3339 --to RFC3339] [--max N]"))
+		return rt.failErr(usageError("invalid calendar list flags", "Usage: mo calendar list [--from RFC3339 --to RFC3339] [--max N] [--all [--limit N]] [--timezone TZ] [--calendar CALENDAR]"))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("calendar list does not take positional arguments", "Run 'mo calendar list --help'."))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
+	if err := checkTimeZoneFlag(*timeZone); err != nil {
+		return rt.failErr(err)
+	}
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
 	if strings.TrimSpace(*from) != "" {
 		if _, err := time.Parse(time.RFC3339, *from); err != nil {
@@ -79,41 +97,28 @@ func runCalendarList(rt *runtimeState, id identityContext, args []string) int {
 		return rt.failErr(usageError("--from and --to must be provided together", "Provide both --from and --to for calendarView queries."))
 	}
 
-	path := "/v1.0/me/events"
+	id, base, err := rt.resolveCalendar(id, *calendar, false)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	path := base + "/events"
 	q := url.Values{}
-	q.Set("$top", fmt.Sprintf("%d", *max))
-	q.Set("$select", "id,subject,start,end,location,webLink")
+	list.apply(q)
+	q.Set("$select", "id,subject,start,end,location,type,seriesMasterId,webLink")
 	q.Set("$orderby", "start/dateTime")
 	if strings.TrimSpace(*from) != "" {
-		path = "/v1.0/me/calendarView"
+		path = base + "/calendarView"
 		q.Set("startDateTime", strings.TrimSpace(*from))
 		q.Set("endDateTime", strings.TrimSpace(*to))
 	}
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
-
-	var resp struct {
-		Value []map[string]any `json:"value"`
-	}
-	next, err := rt.graphRequest(id, "GET", path, q, nil, &resp)
-	if err != nil {
-		return rt.failErr(err)
-	}
-
-	if rt.globals.Plain {
-		for _, it := range resp.Value {
-			idv, _ := it["id"].(string)
-			subj, _ := it["subject"].(string)
-			_, _ = fmt.Fprintf(rt.stdout, "%s\t%s\n", idv, strings.ReplaceAll(subj, "\t", " "))
-		}
-		if next != "" {
-			_, _ = fmt.Fprintf(rt.stdout, "next_page\t%s\n", next)
-		}
-		return exitcode.Success
-	}
+	tz := rt.calendarTimeZone(id, *timeZone)
+	list.Header = preferTimeZone(tz)
 
-	return rt.writeJSON(map[string]any{"items": resp.Value, "next_page": next})
+	return rt.writeList(id, path, q, *list, func(it map[string]any) string {
+		idv, _ := it["id"].(string)
+		subj, _ := it["subject"].(string)
+		return fmt.Sprintf("%s\t%s", idv, strings.ReplaceAll(subj, "\t", " "))
+	}, map[string]any{"time_zone": tz})
 }
 
 func runCalendarCreate(rt *runtimeState, id identityContext, args []string) int {
@@ -125,12 +130,18 @@ func runCalendarCreate(rt *runtimeState, id identityContext, args []string) int
 	description := fs.String("description", "", "Description")
 	location := fs.String("location", "", "Location")
 	attendees := fs.String("attendees", "", "Comma-separated attendees")
+	timeZone := fs.String("timezone", "", "Event time zone (default: mailbox time zone)")
+	calendar := fs.String("calendar", "", "Calendar id, name, \"default\", or a user's email (default: your default calendar)")
+	repeat := addRecurrenceFlags(fs)
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid calendar create flags", "Usage: mo calendar create --summary <text> --from <rfc3339> --to <rfc3339>"))
+		return rt.failErr(usageError("invalid calendar create flags", "Usage: mo calendar create --summary <text> --from <rfc3339> --to <rfc3339> [--calendar CALENDAR] [--repeat FREQ [--interval N] [--days MO,WE] [--until DATE|--count N] | --rrule RULE]"))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("calendar create does not take positional arguments", "Run 'mo calendar create --help'."))
 	}
+	if err := checkTimeZoneFlag(*timeZone); err != nil {
+		return rt.failErr(err)
+	}
 	if strings.TrimSpace(*summary) == "" || strings.TrimSpace(*from) == "" || strings.TrimSpace(*to) == "" {
 		return rt.failErr(usageError("--summary, --from, and --to are required", "Usage: mo calendar create --summary <text> --from <rfc3339> --to <rfc3339>"))
 	}
@@ -145,17 +156,20 @@ func runCalendarCreate(rt *runtimeState, id identityContext, args []string) int
 	if !fromTime.Before(toTime) {
 		return rt.failErr(usageError("--from must be before --to", "Provide a valid event time range."))
 	}
+	recurrence, err := repeat.spec(fs)
+	if err != nil {
+		return rt.failErr(err)
+	}
 
+	id, base, err := rt.resolveCalendar(id, *calendar, true)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	tz := rt.calendarTimeZone(id, *timeZone)
 	payload := map[string]any{
 		"subject": *summary,
-		"start": map[string]any{
-			"dateTime": fromTime.UTC().Format(time.RFC3339),
-			"timeZone": "UTC",
-		},
-		"end": map[string]any{
-			"dateTime": toTime.UTC().Format(time.RFC3339),
-			"timeZone": "UTC",
-		},
+		"start":   eventDateTime(fromTime, tz),
+		"end":     eventDateTime(toTime, tz),
 	}
 	if strings.TrimSpace(*description) != "" {
 		payload["body"] = map[string]any{"contentType": "Text", "content": *description}
@@ -171,9 +185,17 @@ func runCalendarCreate(rt *runtimeState, id identityContext, args []string) int
 		}
 		payload["attendees"] = at
 	}
+	if recurrence != nil {
+		// The series starts on the first event's date in the event's zone.
+		r, err := recurrence.patternedRecurrence(fromTime, tz)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		payload["recurrence"] = r
+	}
 
 	var out map[string]any
-	_, err = rt.graphRequest(id, "POST", "/v1.0/me/events", nil, payload, &out)
+	_, err = rt.graphRequestHeader(id, "POST", rt.graphURL(base+"/events", nil), preferTimeZone(tz), payload, &out)
 	if err != nil {
 		return rt.failErr(err)
 	}
@@ -189,8 +211,12 @@ func runCalendarUpdate(rt *runtimeState, id identityContext, args []string) int
 	description := fs.String("description", "", "Description")
 	location := fs.String("location", "", "Location")
 	attendees := fs.String("attendees", "", "Comma-separated attendees")
+	timeZone := fs.String("timezone", "", "Event time zone (default: mailbox time zone)")
+	calendar := fs.String("calendar", "", "Calendar the event is in: id, name, \"default\", or a user's email")
+	series := fs.Bool("series", false, "Update the whole series the occurrence belongs to")
+	occurrence := fs.String("occurrence", "", "Update only the series' occurrence on this date (YYYY-MM-DD)")
 	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
-		return rt.failErr(usageError("invalid calendar update flags", "Usage: mo calendar update <event-id> [--summary ...] [--from ...] [--to ...]"))
+		return rt.failErr(usageError("invalid calendar update flags", "Usage: mo calendar update <event-id> [--summary ...] [--from ...] [--to ...] [--series|--occurrence DATE]"))
 	}
 	if fs.NArg() != 1 {
 		return rt.failErr(usageError("event id is required", "Usage: mo calendar update <event-id> [--summary ...] [--from ...] [--to ...]"))
@@ -199,24 +225,28 @@ func runCalendarUpdate(rt *runtimeState, id identityContext, args []string) int
 	if eventID == "" {
 		return rt.failErr(usageError("event id is required", "Usage: mo calendar update <event-id> [--summary ...] [--from ...] [--to ...]"))
 	}
+	if err := checkTimeZoneFlag(*timeZone); err != nil {
+		return rt.failErr(err)
+	}
 
 	payload := map[string]any{}
 	if strings.TrimSpace(*summary) != "" {
 		payload["subject"] = *summary
 	}
+	var fromTime, toTime time.Time
 	if strings.TrimSpace(*from) != "" {
 		t, err := time.Parse(time.RFC3339, *from)
 		if err != nil {
 			return rt.failErr(usageError("invalid --from timestamp", "Use RFC3339 format."))
 		}
-		payload["start"] = map[string]any{"dateTime": t.UTC().Format(time.RFC3339), "timeZone": "UTC"}
+		fromTime = t
 	}
 	if strings.TrimSpace(*to) != "" {
 		t, err := time.Parse(time.RFC3339, *to)
 		if err != nil {
 			return rt.failErr(usageError("invalid --to timestamp", "Use RFC3339 format."))
 		}
-		payload["end"] = map[string]any{"dateTime": t.UTC().Format(time.RFC3339), "timeZone": "UTC"}
+		toTime = t
 	}
 	if strings.TrimSpace(*description) != "" {
 		payload["body"] = map[string]any{"contentType": "Text", "content": *description}
@@ -232,12 +262,28 @@ func runCalendarUpdate(rt *runtimeState, id identityContext, args []string) int
 		}
 		payload["attendees"] = at
 	}
-	if len(payload) == 0 {
+	if len(payload) == 0 && fromTime.IsZero() && toTime.IsZero() {
 		return rt.failErr(usageError("no update fields specified", "Provide at least one update flag."))
 	}
+	id, base, err := rt.resolveCalendar(id, *calendar, true)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	tz := rt.calendarTimeZone(id, *timeZone)
+	if !fromTime.IsZero() {
+		payload["start"] = eventDateTime(fromTime, tz)
+	}
+	if !toTime.IsZero() {
+		payload["end"] = eventDateTime(toTime, tz)
+	}
+
+	eventID, err = rt.eventTarget(id, base, eventID, *series, strings.TrimSpace(*occurrence), tz)
+	if err != nil {
+		return rt.failErr(err)
+	}
 
 	var out map[string]any
-	_, err := rt.graphRequest(id, "PATCH", "/v1.0/me/events/"+url.PathEscape(eventID), nil, payload, &out)
+	_, err = rt.graphRequestHeader(id, "PATCH", rt.graphURL(base+"/events/"+url.PathEscape(eventID), nil), preferTimeZone(tz), payload, &out)
 	if err != nil {
 		return rt.failErr(err)
 	}
@@ -248,14 +294,39 @@ func runCalendarUpdate(rt *runtimeState, id identityContext, args []string) int
 }
 
 func runCalendarDelete(rt *runtimeState, id identityContext, args []string) int {
-	if len(args) != 1 {
-		return rt.failErr(usageError("event id is required", "Usage: mo calendar delete <event-id>"))
+	const usage = "Usage: mo calendar delete <event-id> [--calendar CALENDAR] [--series|--occurrence DATE]"
+	fs := flag.NewFlagSet("calendar delete", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	calendar := fs.String("calendar", "", "Calendar the event is in: id, name, \"default\", or a user's email")
+	series := fs.Bool("series", false, "Delete the whole series the occurrence belongs to")
+	occurrence := fs.String("occurrence", "", "Delete only the series' occurrence on this date (YYYY-MM-DD)")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid calendar delete flags", usage))
 	}
-	eventID := strings.TrimSpace(args[0])
+	if fs.NArg() != 1 {
+		return rt.failErr(usageError("event id is required", usage))
+	}
+	eventID := strings.TrimSpace(fs.Arg(0))
 	if eventID == "" {
-		return rt.failErr(usageError("event id is required", "Usage: mo calendar delete <event-id>"))
+		return rt.failErr(usageError("event id is required", usage))
+	}
+	id, base, err := rt.resolveCalendar(id, *calendar, true)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	tz := ""
+	if strings.TrimSpace(*occurrence) != "" {
+		tz = rt.calendarTimeZone(id, "")
 	}
-	ok, err := confirmAction(rt, "Delete calendar event?")
+	eventID, err = rt.eventTarget(id, base, eventID, *series, strings.TrimSpace(*occurrence), tz)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	prompt := "Delete calendar event?"
+	if *series {
+		prompt = "Delete every event in the series?"
+	}
+	ok, err := confirmAction(rt, prompt)
 	if err != nil {
 		return rt.failErr(err)
 	}
@@ -263,9 +334,70 @@ func runCalendarDelete(rt *runtimeState, id identityContext, args []string) int
 		return rt.writeJSON(map[string]any{"deleted": false, "id": eventID})
 	}
 
-	_, err = rt.graphRequest(id, "DELETE", "/v1.0/me/events/"+url.PathEscape(eventID), nil, nil, nil)
+	_, err = rt.graphRequest(id, "DELETE", base+"/events/"+url.PathEscape(eventID), nil, nil, nil)
 	if err != nil {
 		return rt.failErr(err)
 	}
 	return rt.writeJSON(map[string]any{"deleted": true, "id": eventID})
 }
+
+// calendarTimeZone picks the zone for calendar times: --timezone, then the
+// mailbox time zone from mailboxSettings, then UTC. The mailbox zone is read
+// once per process.
+func (rt *runtimeState) calendarTimeZone(id identityContext, flagValue string) string {
+	if tz := strings.TrimSpace(flagValue); tz != "" {
+		return tz
+	}
+	if rt.mailboxZone != "" {
+		return rt.mailboxZone
+	}
+	tz, err := rt.mailboxTimeZone(id)
+	switch {
+	case err != nil:
+		hint := "pass --timezone to choose one"
+		var ae *appError
+		if errors.As(err, &ae) && ae.Code == "permission_denied" {
+			// Logins from before MailboxSettings.ReadWrite was requested.
+			hint = fmt.Sprintf("grant MailboxSettings.ReadWrite with 'mo auth add %s --force-consent', or pass --timezone", id.Account)
+		}
+		_, _ = fmt.Fprintf(rt.stderr, "warning: could not read mailbox time zone, using UTC (%s): %v\n", hint, err)
+		tz = "UTC"
+	case tz == "":
+		tz = "UTC"
+	}
+	rt.mailboxZone = tz
+	return tz
+}
+
+// checkTimeZoneFlag rejects a --timezone that names no known zone, which
+// would otherwise quietly turn into UTC.
+func checkTimeZoneFlag(v string) error {
+	tz := strings.TrimSpace(v)
+	if tz == "" {
+		return nil
+	}
+	if _, ok := loadTimeZone(tz); !ok {
+		return usageError(fmt.Sprintf("unknown time zone %q", tz), "Pass an IANA name such as Europe/Berlin or a Windows name such as W. Europe Standard Time.")
+	}
+	return nil
+}
+
+// preferTimeZone asks Graph to return event times in tz.
+func preferTimeZone(tz string) http.Header {
+	h := http.Header{}
+	h.Set("Prefer", `outlook.timezone="`+tz+`"`)
+	return h
+}
+
+// eventDateTime anchors t in tz when the zone is known, so the event keeps
+// its wall-clock time across DST changes. Windows names, which Exchange
+// reports for mailboxes, are converted through their IANA equivalent and sent
+// as given. Unknown zones are sent as the equivalent UTC time.
+func eventDateTime(t time.Time, tz string) map[string]any {
+	if !strings.EqualFold(tz, "UTC") {
+		if loc, ok := loadTimeZone(tz); ok {
+			return map[string]any{"dateTime": t.In(loc).Format("2006-01-02T15:04:05"), "timeZone": strings.TrimSpace(tz)}
+		}
+	}
+	return map[string]any{"dateTime": t.UTC().Format(time.RFC3339), "timeZone": "UTC"}
+}
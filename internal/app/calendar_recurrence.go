@@ -0,0 +1,425 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rruleWeekdays = map[string]string{
+	"mo": "monday", "tu": "tuesday", "we": "wednesday", "th": "thursday", "fr": "friday", "sa": "saturday", "su": "sunday",
+}
+
+var recurrenceIndexes = map[int]string{1: "first", 2: "second", 3: "third", 4: "fourth", -1: "last"}
+
+// recurrenceDay is one BYDAY entry: a weekday with an optional ordinal such
+// as 2 in "2TU" (second Tuesday) or -1 in "-1FR" (last Friday).
+type recurrenceDay struct {
+	Ordinal int
+	Day     string
+}
+
+// recurrenceSpec is the subset of RFC 5545 recurrence rules that Graph's
+// patternedRecurrence can express.
+type recurrenceSpec struct {
+	Freq      string
+	Interval  int
+	Days      []recurrenceDay
+	MonthDay  int
+	Month     int
+	SetPos    int
+	Count     int
+	Until     string
+	WeekStart string
+}
+
+type recurrenceFlags struct {
+	repeat, days, until, rrule *string
+	interval, count            *int
+}
+
+func addRecurrenceFlags(fs *flag.FlagSet) *recurrenceFlags {
+	return &recurrenceFlags{
+		repeat:   fs.String("repeat", "", "Repeat the event: daily|weekly|monthly|yearly"),
+		interval: fs.Int("interval", 1, "Repeat every N days, weeks, months or years"),
+		days:     fs.String("days", "", "Weekdays, e.g. MO,WE; with monthly or yearly an ordinal such as 2TU or -1FR"),
+		until:    fs.String("until", "", "Last date of the series (YYYY-MM-DD or RFC3339)"),
+		count:    fs.Int("count", 0, "Number of occurrences"),
+		rrule:    fs.String("rrule", "", "RFC 5545 rule instead of --repeat, e.g. FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"),
+	}
+}
+
+// spec returns the recurrence the flags describe, or nil when none was asked
+// for.
+func (f *recurrenceFlags) spec(fs *flag.FlagSet) (*recurrenceSpec, error) {
+	set := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	if strings.TrimSpace(*f.rrule) != "" {
+		for _, name := range []string{"repeat", "interval", "days", "until", "count"} {
+			if set[name] {
+				return nil, usageError(fmt.Sprintf("--%s cannot be combined with --rrule", name), "Put the whole rule in --rrule.")
+			}
+		}
+		return parseRRule(*f.rrule)
+	}
+	if strings.TrimSpace(*f.repeat) == "" {
+		for _, name := range []string{"interval", "days", "until", "count"} {
+			if set[name] {
+				return nil, usageError(fmt.Sprintf("--%s requires --repeat", name), "Use --repeat daily|weekly|monthly|yearly.")
+			}
+		}
+		return nil, nil
+	}
+
+	spec := &recurrenceSpec{Freq: strings.ToLower(strings.TrimSpace(*f.repeat)), Interval: *f.interval, Count: *f.count}
+	switch spec.Freq {
+	case "daily", "weekly", "monthly", "yearly":
+	default:
+		return nil, usageError(fmt.Sprintf("invalid --repeat %q", *f.repeat), "Use daily, weekly, monthly or yearly.")
+	}
+	if spec.Interval < 1 {
+		return nil, usageError("--interval must be at least 1", "Use --interval 2 for every other day, week, month or year.")
+	}
+	if spec.Count < 0 {
+		return nil, usageError("--count must not be negative", "Use --count N for N occurrences.")
+	}
+	if strings.TrimSpace(*f.days) != "" {
+		days, err := parseRecurrenceDays(*f.days)
+		if err != nil {
+			return nil, err
+		}
+		spec.Days = days
+	}
+	if v := strings.TrimSpace(*f.until); v != "" {
+		spec.Until = v
+	}
+	return spec, nil
+}
+
+// parseRRule reads an RRULE such as "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20261231".
+func parseRRule(rule string) (*recurrenceSpec, error) {
+	rule = strings.TrimSpace(rule)
+	if len(rule) > 6 && strings.EqualFold(rule[:6], "RRULE:") {
+		rule = rule[6:]
+	}
+	spec := &recurrenceSpec{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, usageError(fmt.Sprintf("invalid --rrule part %q", part), "Use KEY=VALUE pairs separated by ';'.")
+		}
+		key, value = strings.ToUpper(strings.TrimSpace(key)), strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "FREQ":
+			spec.Freq = strings.ToLower(value)
+		case "INTERVAL":
+			spec.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			spec.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			spec.Until = value
+		case "BYDAY":
+			spec.Days, err = parseRecurrenceDays(value)
+		case "BYMONTHDAY":
+			spec.MonthDay, err = strconv.Atoi(value)
+		case "BYMONTH":
+			spec.Month, err = strconv.Atoi(value)
+		case "BYSETPOS":
+			spec.SetPos, err = strconv.Atoi(value)
+		case "WKST":
+			spec.WeekStart = rruleWeekdays[strings.ToLower(value)]
+			if spec.WeekStart == "" {
+				err = fmt.Errorf("unknown weekday")
+			}
+		default:
+			return nil, usageError(fmt.Sprintf("--rrule %s is not supported", key), "Graph recurrences support FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and WKST.")
+		}
+		if err != nil {
+			return nil, usageError(fmt.Sprintf("invalid --rrule %s value %q", key, value), err.Error())
+		}
+	}
+	switch spec.Freq {
+	case "daily", "weekly", "monthly", "yearly":
+	case "":
+		return nil, usageError("--rrule needs FREQ", "e.g. FREQ=WEEKLY;BYDAY=MO,WE")
+	default:
+		return nil, usageError(fmt.Sprintf("--rrule FREQ=%s is not supported", strings.ToUpper(spec.Freq)), "Use DAILY, WEEKLY, MONTHLY or YEARLY.")
+	}
+	if spec.Interval < 1 || spec.Count < 0 {
+		return nil, usageError("invalid --rrule INTERVAL or COUNT", "INTERVAL must be at least 1 and COUNT must not be negative.")
+	}
+	return spec, nil
+}
+
+// parseRecurrenceDays reads "MO,WE" or ordinal forms such as "2TU" and "-1FR".
+func parseRecurrenceDays(v string) ([]recurrenceDay, error) {
+	out := make([]recurrenceDay, 0)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if len(part) < 2 {
+			return nil, usageError(fmt.Sprintf("invalid day %q", part), "Use MO, TU, WE, TH, FR, SA or SU, optionally with an ordinal such as 2TU or -1FR.")
+		}
+		day, ok := rruleWeekdays[part[len(part)-2:]]
+		if !ok {
+			return nil, usageError(fmt.Sprintf("invalid day %q", part), "Use MO, TU, WE, TH, FR, SA or SU, optionally with an ordinal such as 2TU or -1FR.")
+		}
+		d := recurrenceDay{Day: day}
+		if prefix := strings.TrimPrefix(part[:len(part)-2], "+"); prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if _, known := recurrenceIndexes[n]; err != nil || !known {
+				return nil, usageError(fmt.Sprintf("invalid day ordinal in %q", part), "Graph supports 1 to 4 and -1 (last).")
+			}
+			d.Ordinal = n
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// patternedRecurrence converts spec into Graph's recurrence for an event
+// starting at start. The range is anchored in the same zone eventDateTime
+// uses for the event, so the dates line up with its local start.
+func (spec *recurrenceSpec) patternedRecurrence(start time.Time, tz string) (map[string]any, error) {
+	loc, zone := time.UTC, "UTC"
+	if !strings.EqualFold(tz, "UTC") {
+		if l, ok := loadTimeZone(tz); ok {
+			loc, zone = l, tz
+		}
+	}
+	local := start.In(loc)
+
+	ordinal := 0
+	days := make([]string, 0, len(spec.Days))
+	for _, d := range spec.Days {
+		if d.Ordinal != 0 {
+			if ordinal != 0 && ordinal != d.Ordinal {
+				return nil, usageError("all days must use the same ordinal", "Graph supports one week index per recurrence, e.g. 2TU or 2TU,2TH.")
+			}
+			ordinal = d.Ordinal
+		}
+		days = append(days, d.Day)
+	}
+	if spec.SetPos != 0 {
+		if _, ok := recurrenceIndexes[spec.SetPos]; !ok || ordinal != 0 {
+			return nil, usageError("unsupported BYSETPOS", "Graph supports 1 to 4 and -1 (last), without ordinals in BYDAY.")
+		}
+		ordinal = spec.SetPos
+	}
+
+	pattern := map[string]any{"interval": spec.Interval}
+	freq := spec.Freq
+	if freq == "daily" && len(days) > 0 {
+		// "Every weekday" style rules are weekly patterns in Graph.
+		freq = "weekly"
+	}
+	switch freq {
+	case "daily":
+		pattern["type"] = "daily"
+	case "weekly":
+		if ordinal != 0 {
+			return nil, usageError("weekly recurrences cannot use day ordinals", "Use --repeat monthly for days such as 2TU.")
+		}
+		if len(days) == 0 {
+			days = []string{strings.ToLower(local.Weekday().String())}
+		}
+		pattern["type"] = "weekly"
+		pattern["daysOfWeek"] = days
+		pattern["firstDayOfWeek"] = "sunday"
+		if spec.WeekStart != "" {
+			pattern["firstDayOfWeek"] = spec.WeekStart
+		}
+	case "monthly", "yearly":
+		relative := ordinal != 0
+		if len(days) > 0 && !relative {
+			return nil, usageError(fmt.Sprintf("%s recurrences need an ordinal with --days", freq), "Use e.g. 2TU for the second Tuesday or -1FR for the last Friday.")
+		}
+		if relative {
+			pattern["type"] = "relative" + strings.ToUpper(freq[:1]) + freq[1:]
+			pattern["daysOfWeek"] = days
+			pattern["index"] = recurrenceIndexes[ordinal]
+		} else {
+			pattern["type"] = "absolute" + strings.ToUpper(freq[:1]) + freq[1:]
+			pattern["dayOfMonth"] = local.Day()
+			if spec.MonthDay != 0 {
+				if spec.MonthDay < 1 || spec.MonthDay > 31 {
+					return nil, usageError("BYMONTHDAY must be between 1 and 31", "Graph does not support negative month days.")
+				}
+				pattern["dayOfMonth"] = spec.MonthDay
+			}
+		}
+		if freq == "yearly" {
+			pattern["month"] = int(local.Month())
+			if spec.Month != 0 {
+				if spec.Month < 1 || spec.Month > 12 {
+					return nil, usageError("BYMONTH must be between 1 and 12", "Graph supports one month per yearly recurrence.")
+				}
+				pattern["month"] = spec.Month
+			}
+		}
+	}
+
+	rng := map[string]any{
+		"type":               "noEnd",
+		"startDate":          local.Format("2006-01-02"),
+		"recurrenceTimeZone": zone,
+	}
+	switch {
+	case spec.Count > 0 && spec.Until != "":
+		return nil, usageError("pass either an end date or a count, not both", "Use --until or --count (UNTIL or COUNT in --rrule).")
+	case spec.Count > 0:
+		rng["type"] = "numbered"
+		rng["numberOfOccurrences"] = spec.Count
+	case spec.Until != "":
+		end, err := recurrenceEndDate(spec.Until, loc)
+		if err != nil {
+			return nil, err
+		}
+		if end < rng["startDate"].(string) {
+			return nil, usageError("the series ends before it starts", "Pick an --until date on or after the first occurrence.")
+		}
+		rng["type"] = "endDate"
+		rng["endDate"] = end
+	}
+	return map[string]any{"pattern": pattern, "range": rng}, nil
+}
+
+// recurrenceEndDate accepts YYYY-MM-DD, RFC3339 and the RRULE forms
+// YYYYMMDD and YYYYMMDDTHHMMSSZ, and returns the date in loc.
+func recurrenceEndDate(v string, loc *time.Location) (string, error) {
+	v = strings.TrimSpace(v)
+	for _, layout := range []string{"2006-01-02", "20060102"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	for _, layout := range []string{time.RFC3339, "20060102T150405Z"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.In(loc).Format("2006-01-02"), nil
+		}
+	}
+	return "", usageError(fmt.Sprintf("invalid end date %q", v), "Use YYYY-MM-DD or RFC3339.")
+}
+
+func runCalendarInstances(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo calendar instances <series-id> --from RFC3339 --to RFC3339 [--max N] [--page TOKEN] [--all [--limit N]] [--timezone TZ] [--calendar CALENDAR]"
+	fs := flag.NewFlagSet("calendar instances", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	list := addListFlags(fs, 50)
+	from := fs.String("from", "", "Start RFC3339")
+	to := fs.String("to", "", "End RFC3339")
+	timeZone := fs.String("timezone", "", "Time zone for returned times (default: mailbox time zone)")
+	calendar := fs.String("calendar", "", "Calendar the series is in: id, name, \"default\", or a user's email")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid calendar instances flags", usage))
+	}
+	if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+		return rt.failErr(usageError("series id is required", usage))
+	}
+	if err := checkTimeZoneFlag(*timeZone); err != nil {
+		return rt.failErr(err)
+	}
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
+	}
+	for _, f := range []struct{ name, value string }{{"--from", *from}, {"--to", *to}} {
+		name, v := f.name, f.value
+		if strings.TrimSpace(v) == "" {
+			return rt.failErr(usageError(name+" is required", usage))
+		}
+		if _, err := time.Parse(time.RFC3339, strings.TrimSpace(v)); err != nil {
+			return rt.failErr(usageError("invalid "+name+" timestamp", "Use RFC3339 format."))
+		}
+	}
+	id, base, err := rt.resolveCalendar(id, *calendar, false)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	q := url.Values{}
+	list.apply(q)
+	q.Set("startDateTime", strings.TrimSpace(*from))
+	q.Set("endDateTime", strings.TrimSpace(*to))
+	q.Set("$select", "id,subject,start,end,location,type,seriesMasterId,isCancelled,webLink")
+	tz := rt.calendarTimeZone(id, *timeZone)
+	list.Header = preferTimeZone(tz)
+	path := base + "/events/" + url.PathEscape(strings.TrimSpace(fs.Arg(0))) + "/instances"
+	return rt.writeList(id, path, q, *list, func(it map[string]any) string {
+		start, _ := it["start"].(map[string]any)
+		return fmt.Sprintf("%s\t%s\t%s", asString(it["id"]), asString(start["dateTime"]), strings.ReplaceAll(asString(it["subject"]), "\t", " "))
+	}, map[string]any{"time_zone": tz})
+}
+
+// eventTarget picks the event an update or delete applies to. An
+// occurrence id changes just that occurrence and a series id the whole
+// series; series moves from an occurrence to its series, and occurrence
+// (YYYY-MM-DD) from a series to its occurrence on that date.
+func (rt *runtimeState) eventTarget(id identityContext, base, eventID string, series bool, occurrence, tz string) (string, error) {
+	if series && occurrence != "" {
+		return "", usageError("--series and --occurrence cannot be combined", "Use --series for the whole series or --occurrence DATE for one occurrence.")
+	}
+	if series {
+		q := url.Values{}
+		q.Set("$select", "id,type,seriesMasterId")
+		var ev struct {
+			Type           string `json:"type"`
+			SeriesMasterID string `json:"seriesMasterId"`
+		}
+		if _, err := rt.graphRequest(id, http.MethodGet, base+"/events/"+url.PathEscape(eventID), q, nil, &ev); err != nil {
+			return "", err
+		}
+		switch ev.Type {
+		case "seriesMaster":
+			return eventID, nil
+		case "occurrence", "exception":
+			return ev.SeriesMasterID, nil
+		default:
+			return "", usageError("event is not part of a series", "Drop --series for single events.")
+		}
+	}
+	if occurrence == "" {
+		return eventID, nil
+	}
+
+	date := strings.TrimSpace(occurrence)
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", usageError("invalid --occurrence date", "Use YYYY-MM-DD, the date of the occurrence.")
+	}
+	zone := "UTC"
+	if _, ok := loadTimeZone(tz); ok {
+		zone = tz
+	}
+	// Search a day either side, which covers any UTC offset, and match the
+	// date against the start Graph reports in the calendar's own zone.
+	q := url.Values{}
+	q.Set("startDateTime", day.AddDate(0, 0, -1).Format(time.RFC3339))
+	q.Set("endDateTime", day.AddDate(0, 0, 2).Format(time.RFC3339))
+	q.Set("$select", "id,start")
+	var resp struct {
+		Value []struct {
+			ID    string `json:"id"`
+			Start struct {
+				DateTime string `json:"dateTime"`
+			} `json:"start"`
+		} `json:"value"`
+	}
+	u := rt.graphURL(base+"/events/"+url.PathEscape(eventID)+"/instances", q)
+	if _, err := rt.graphRequestHeader(id, http.MethodGet, u, preferTimeZone(zone), nil, &resp); err != nil {
+		return "", err
+	}
+	for _, it := range resp.Value {
+		if strings.HasPrefix(it.Start.DateTime, date) {
+			return it.ID, nil
+		}
+	}
+	return "", notFoundError(fmt.Sprintf("no occurrence on %s", occurrence), "Run 'mo calendar instances <series-id> --from ... --to ...' to list occurrences.")
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPatternedRecurrence(t *testing.T) {
+	// Tuesday 2026-03-10 09:00 in Berlin.
+	start := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	cases := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY;COUNT=5", `{"pattern":{"interval":1,"type":"daily"},"range":{"numberOfOccurrences":5,"recurrenceTimeZone":"Europe/Berlin","startDate":"2026-03-10","type":"numbered"}}`},
+		{"FREQ=WEEKLY;INTERVAL=2", `{"pattern":{"daysOfWeek":["tuesday"],"firstDayOfWeek":"sunday","interval":2,"type":"weekly"},"range":{"recurrenceTimeZone":"Europe/Berlin","startDate":"2026-03-10","type":"noEnd"}}`},
+		{"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20260331T220000Z", `{"pattern":{"daysOfWeek":["monday","tuesday","wednesday","thursday","friday"],"firstDayOfWeek":"sunday","interval":1,"type":"weekly"},"range":{"endDate":"2026-04-01","recurrenceTimeZone":"Europe/Berlin","startDate":"2026-03-10","type":"endDate"}}`},
+		{"FREQ=MONTHLY;BYDAY=-1FR", `{"pattern":{"daysOfWeek":["friday"],"index":"last","interval":1,"type":"relativeMonthly"},"range":{"recurrenceTimeZone":"Europe/Berlin","startDate":"2026-03-10","type":"noEnd"}}`},
+		{"FREQ=MONTHLY;BYDAY=TU;BYSETPOS=2", `{"pattern":{"daysOfWeek":["tuesday"],"index":"second","interval":1,"type":"relativeMonthly"},"range":{"recurrenceTimeZone":"Europe/Berlin","startDate":"2026-03-10","type":"noEnd"}}`},
+		{"FREQ=YEARLY", `{"pattern":{"dayOfMonth":10,"interval":1,"month":3,"type":"absoluteYearly"},"range":{"recurrenceTimeZone":"Europe/Berlin","startDate":"2026-03-10","type":"noEnd"}}`},
+	}
+	for _, tc := range cases {
+		spec, err := parseRRule(tc.rule)
+		if err != nil {
+			t.Fatalf("parseRRule(%q): %v", tc.rule, err)
+		}
+		got, err := spec.patternedRecurrence(start, "Europe/Berlin")
+		if err != nil {
+			t.Fatalf("patternedRecurrence(%q): %v", tc.rule, err)
+		}
+		b, _ := json.Marshal(got)
+		if string(b) != tc.want {
+			t.Fatalf("%s:\n got %s\nwant %s", tc.rule, b, tc.want)
+		}
+	}
+
+	// Windows names, as Exchange reports them, anchor the range the same way.
+	spec, _ := parseRRule("FREQ=DAILY")
+	late := time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC)
+	got, err := spec.patternedRecurrence(late, "W. Europe Standard Time")
+	if err != nil {
+		t.Fatalf("patternedRecurrence(Windows zone): %v", err)
+	}
+	if rng := got["range"].(map[string]any); rng["recurrenceTimeZone"] != "W. Europe Standard Time" || rng["startDate"] != "2026-03-11" {
+		t.Fatalf("unexpected range for Windows zone: %v", rng)
+	}
+
+	for _, rule := range []string{"FREQ=HOURLY", "FREQ=WEEKLY;BYHOUR=9", "FREQ=WEEKLY;BYDAY=2TU", "FREQ=MONTHLY;BYDAY=MO", "FREQ=DAILY;COUNT=3;UNTIL=20260401", "FREQ=DAILY;UNTIL=20260301"} {
+		spec, err := parseRRule(rule)
+		if err == nil {
+			_, err = spec.patternedRecurrence(start, "Europe/Berlin")
+		}
+		if err == nil {
+			t.Fatalf("expected %q to be rejected", rule)
+		}
+	}
+}
+
+func TestCalendarCreateRecurrence(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"id":"series-1"}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	code := runCalendarCreate(rt, identityContext{Account: "me@contoso.com"}, []string{
+		"--summary", "Standup", "--from", "2026-03-10T09:00:00Z", "--to", "2026-03-10T09:15:00Z", "--timezone", "UTC",
+		"--repeat", "weekly", "--days", "MO,WE", "--count", "10",
+	})
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, out.String())
+	}
+	rec, _ := body["recurrence"].(map[string]any)
+	pattern, _ := rec["pattern"].(map[string]any)
+	rng, _ := rec["range"].(map[string]any)
+	if pattern["type"] != "weekly" || len(pattern["daysOfWeek"].([]any)) != 2 || rng["type"] != "numbered" || rng["numberOfOccurrences"] != float64(10) {
+		t.Fatalf("unexpected recurrence: %v", rec)
+	}
+
+	out.Reset()
+	if code := runCalendarCreate(rt, identityContext{}, []string{"--summary", "x", "--from", "2026-03-10T09:00:00Z", "--to", "2026-03-10T10:00:00Z", "--timezone", "UTC", "--count", "3"}); code == 0 {
+		t.Fatalf("expected --count without --repeat to fail")
+	}
+	if code := runCalendarCreate(rt, identityContext{}, []string{"--summary", "x", "--from", "2026-03-10T09:00:00Z", "--to", "2026-03-10T10:00:00Z", "--timezone", "UTC", "--repeat", "daily", "--rrule", "FREQ=DAILY"}); code == 0 {
+		t.Fatalf("expected --repeat with --rrule to fail")
+	}
+}
+
+func TestEventTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1.0/me/events/occ-1":
+			_, _ = w.Write([]byte(`{"id":"occ-1","type":"occurrence","seriesMasterId":"series-1"}`))
+		case "/v1.0/me/events/single":
+			_, _ = w.Write([]byte(`{"id":"single","type":"singleInstance"}`))
+		case "/v1.0/me/events/series-1/instances":
+			// A 23:30 occurrence in Berlin starts on the previous day in UTC,
+			// so it is only found through the padded window and the local
+			// start Graph returns under the Prefer header.
+			q := r.URL.Query()
+			if q.Get("startDateTime") != "2026-03-16T00:00:00Z" || q.Get("endDateTime") != "2026-03-19T00:00:00Z" || r.Header.Get("Prefer") != `outlook.timezone="W. Europe Standard Time"` {
+				_, _ = w.Write([]byte(`{"value":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"value":[` +
+				`{"id":"occ-1","start":{"dateTime":"2026-03-16T23:30:00.0000000","timeZone":"W. Europe Standard Time"}},` +
+				`{"id":"occ-2","start":{"dateTime":"2026-03-17T23:30:00.0000000","timeZone":"W. Europe Standard Time"}}` +
+				`]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	id := identityContext{Account: "me@contoso.com"}
+	if got, err := rt.eventTarget(id, "/v1.0/me", "occ-1", true, "", "UTC"); err != nil || got != "series-1" {
+		t.Fatalf("series target = %q, %v", got, err)
+	}
+	if got, err := rt.eventTarget(id, "/v1.0/me", "series-1", false, "2026-03-17", "W. Europe Standard Time"); err != nil || got != "occ-2" {
+		t.Fatalf("occurrence target = %q, %v", got, err)
+	}
+	if _, err := rt.eventTarget(id, "/v1.0/me", "series-1", false, "2026-03-18", "W. Europe Standard Time"); err == nil {
+		t.Fatalf("expected a date without an occurrence to fail")
+	}
+	if _, err := rt.eventTarget(id, "/v1.0/me", "single", true, "", "UTC"); err == nil {
+		t.Fatalf("expected --series on a single event to fail")
+	}
+}
@@ -0,0 +1,207 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+// categoryColors maps Outlook's color names to Graph's categoryColor presets.
+// The master category list is shared by mail, calendar and contacts.
+var categoryColors = []struct{ name, preset string }{
+	{"red", "preset0"},
+	{"orange", "preset1"},
+	{"brown", "preset2"},
+	{"yellow", "preset3"},
+	{"green", "preset4"},
+	{"teal", "preset5"},
+	{"olive", "preset6"},
+	{"blue", "preset7"},
+	{"purple", "preset8"},
+	{"cranberry", "preset9"},
+	{"steel", "preset10"},
+	{"darksteel", "preset11"},
+	{"gray", "preset12"},
+	{"darkgray", "preset13"},
+	{"black", "preset14"},
+	{"darkred", "preset15"},
+	{"darkorange", "preset16"},
+	{"darkbrown", "preset17"},
+	{"darkyellow", "preset18"},
+	{"darkgreen", "preset19"},
+	{"darkteal", "preset20"},
+	{"darkolive", "preset21"},
+	{"darkblue", "preset22"},
+	{"darkpurple", "preset23"},
+	{"darkcranberry", "preset24"},
+}
+
+// categoryColor accepts a color name ("dark blue", "darkBlue"), a preset
+// ("preset7") or "none".
+func categoryColor(v string) (string, bool) {
+	v = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+	v = strings.ReplaceAll(v, "grey", "gray")
+	if v == "none" {
+		return "none", true
+	}
+	for _, c := range categoryColors {
+		if v == c.name || v == c.preset {
+			return c.preset, true
+		}
+	}
+	return "", false
+}
+
+func categoryColorName(preset string) string {
+	for _, c := range categoryColors {
+		if strings.EqualFold(preset, c.preset) {
+			return c.name
+		}
+	}
+	return "none"
+}
+
+// mergeCategories applies add and remove to current. Outlook treats
+// category names case-insensitively, so matching ignores case and the
+// existing spelling is kept.
+func mergeCategories(current, add, remove []string) []string {
+	drop := map[string]bool{}
+	for _, c := range remove {
+		drop[strings.ToLower(c)] = true
+	}
+	seen := map[string]bool{}
+	out := make([]string, 0, len(current)+len(add))
+	for _, list := range [][]string{current, add} {
+		for _, c := range list {
+			key := strings.ToLower(strings.TrimSpace(c))
+			if key == "" || seen[key] || drop[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, strings.TrimSpace(c))
+		}
+	}
+	return out
+}
+
+// splitCategories flattens repeated, comma-separated category flags.
+func splitCategories(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+func masterCategoriesPath(id identityContext) string {
+	return mailRoot(id) + "/outlook/masterCategories"
+}
+
+func runMailCategories(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
+		return exitcode.Success
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	rest := args[1:]
+	switch sub {
+	case "list":
+		if len(rest) != 0 {
+			return rt.failErr(usageError("mail categories list does not take arguments", "Usage: mo mail categories list"))
+		}
+		return rt.writeItems(func(each func([]map[string]any) error) (string, error) {
+			return rt.listPages(id, masterCategoriesPath(id), nil, listOptions{All: true}, func(items []map[string]any) error {
+				for _, it := range items {
+					it["color_name"] = categoryColorName(asString(it["color"]))
+				}
+				return each(items)
+			})
+		}, func(it map[string]any) string {
+			return fmt.Sprintf("%s\t%s\t%s", asString(it["displayName"]), asString(it["color_name"]), asString(it["id"]))
+		}, nil)
+	case "create":
+		return runMailCategoriesCreate(rt, id, rest)
+	case "delete":
+		return runMailCategoriesDelete(rt, id, rest)
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown mail categories subcommand %q", sub), "Run 'mo mail help' for usage."))
+	}
+}
+
+func runMailCategoriesCreate(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail categories create <name> [--color COLOR]"
+	fs := flag.NewFlagSet("mail categories create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	color := fs.String("color", "none", "Color name (red, blue, darkgreen, ...), preset0..preset24, or none")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid mail categories create flags", usage))
+	}
+	if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+		return rt.failErr(usageError("category name is required", usage))
+	}
+	preset, ok := categoryColor(*color)
+	if !ok {
+		return rt.failErr(usageError(fmt.Sprintf("invalid --color %q", *color), "Use a color such as red, blue or darkgreen, preset0..preset24, or none."))
+	}
+	var out map[string]any
+	body := map[string]any{"displayName": strings.TrimSpace(fs.Arg(0)), "color": preset}
+	if _, err := rt.graphRequest(id, http.MethodPost, masterCategoriesPath(id), nil, body, &out); err != nil {
+		return rt.failErr(err)
+	}
+	delete(out, "@odata.context")
+	out["color_name"] = categoryColorName(asString(out["color"]))
+	return rt.writeJSON(out)
+}
+
+func runMailCategoriesDelete(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail categories delete <name|id>"
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return rt.failErr(usageError("category name or id is required", usage))
+	}
+	ref := strings.TrimSpace(args[0])
+	catID, name, err := rt.findMasterCategory(id, ref)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	ok, err := confirmAction(rt, fmt.Sprintf("Delete category %q? Messages keep the label.", name))
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if !ok {
+		return rt.writeJSON(map[string]any{"deleted": false, "id": catID, "displayName": name})
+	}
+	if _, err := rt.graphRequest(id, http.MethodDelete, masterCategoriesPath(id)+"/"+url.PathEscape(catID), nil, nil, nil); err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{"deleted": true, "id": catID, "displayName": name})
+}
+
+// findMasterCategory looks a category up by display name (ignoring case) or
+// id. The list is small, so it is fetched whole.
+func (rt *runtimeState) findMasterCategory(id identityContext, ref string) (string, string, error) {
+	catID, name := "", ""
+	_, err := rt.listPages(id, masterCategoriesPath(id), nil, listOptions{All: true}, func(items []map[string]any) error {
+		for _, it := range items {
+			if catID == "" && (strings.EqualFold(asString(it["displayName"]), ref) || asString(it["id"]) == ref) {
+				catID, name = asString(it["id"]), asString(it["displayName"])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if catID == "" {
+		return "", "", notFoundError(fmt.Sprintf("category %q not found", ref), "Run 'mo mail categories list'.")
+	}
+	return catID, name, nil
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCategoryColor(t *testing.T) {
+	for in, want := range map[string]string{"red": "preset0", "Dark Blue": "preset22", "grey": "preset12", "preset7": "preset7", "none": "none"} {
+		if got, ok := categoryColor(in); !ok || got != want {
+			t.Fatalf("categoryColor(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := categoryColor("magenta"); ok {
+		t.Fatalf("expected unknown color to be rejected")
+	}
+	if categoryColorName("preset22") != "darkblue" {
+		t.Fatalf("unexpected name for preset22")
+	}
+}
+
+func TestMergeCategories(t *testing.T) {
+	got := mergeCategories([]string{"Red Team", "queued"}, []string{"Done", "red team"}, []string{"Queued"})
+	if want := []string{"Red Team", "Done"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("mergeCategories = %v, want %v", got, want)
+	}
+}
+
+func TestMailCategorizeReadsThenPatches(t *testing.T) {
+	var patched map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch {
+			_ = json.NewDecoder(r.Body).Decode(&patched)
+			_, _ = w.Write([]byte(`{"id":"m1","categories":["Keep","Done"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"m1","categories":["Keep","Queued"]}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runMailCategorize(rt, identityContext{}, []string{"m1", "--add", "Done", "--remove", "queued"}); code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	if got, _ := json.Marshal(patched["categories"]); string(got) != `["Keep","Done"]` {
+		t.Fatalf("unexpected patch %s", got)
+	}
+	if !strings.Contains(out.String(), `"categories":["Keep","Done"]`) {
+		t.Fatalf("unexpected output %s", out.String())
+	}
+}
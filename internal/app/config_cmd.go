@@ -45,14 +45,9 @@ func runConfig(rt *runtimeState, args []string) int {
 		if len(rest) != 2 {
 			return rt.failErr(usageError("config key and value are required", "Usage: mo config set <key> <value>"))
 		}
-		cfg, err := config.LoadAppConfig()
-		if err != nil {
-			return rt.failErr(err)
-		}
-		if err := setConfigKey(&cfg, rest[0], rest[1]); err != nil {
-			return rt.failErr(err)
-		}
-		if err := config.SaveAppConfig(cfg); err != nil {
+		if _, err := config.UpdateAppConfig(func(cfg *config.AppConfig) error {
+			return setConfigKey(cfg, rest[0], rest[1])
+		}); err != nil {
 			return rt.failErr(err)
 		}
 		return rt.writeJSON(map[string]any{"saved": true, "key": strings.ToLower(rest[0]), "value": rest[1]})
@@ -60,14 +55,9 @@ func runConfig(rt *runtimeState, args []string) int {
 		if len(rest) != 1 {
 			return rt.failErr(usageError("config key is required", "Usage: mo config unset <key>"))
 		}
-		cfg, err := config.LoadAppConfig()
-		if err != nil {
-			return rt.failErr(err)
-		}
-		if err := setConfigKey(&cfg, rest[0], ""); err != nil {
-			return rt.failErr(err)
-		}
-		if err := config.SaveAppConfig(cfg); err != nil {
+		if _, err := config.UpdateAppConfig(func(cfg *config.AppConfig) error {
+			return setConfigKey(cfg, rest[0], "")
+		}); err != nil {
 			return rt.failErr(err)
 		}
 		return rt.writeJSON(map[string]any{"unset": true, "key": strings.ToLower(rest[0])})
@@ -1,20 +1,25 @@
 package app
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/svaruag/mocli/internal/config"
 	"github.com/svaruag/mocli/internal/exitcode"
+	"github.com/svaruag/mocli/internal/quickxor"
 )
 
 const driveSmallUploadLimitBytes = 250 * 1024 * 1024
@@ -133,17 +138,16 @@ func runDriveList(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("drive ls", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	parent := fs.String("parent", "", "Parent folder item id")
-	max := fs.Int("max", 100, "Max items")
-	page := fs.String("page", "", "Page token")
+	list := addListFlags(fs, 100)
 	drive := fs.String("drive", "", "Drive container id")
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid drive ls flags", "Usage: mo drive ls [--parent ID] [--max N] [--page TOKEN] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("invalid drive ls flags", "Usage: mo drive ls [--parent ID] [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]"))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("drive ls does not take positional arguments", "Run 'mo drive ls --help'."))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
 
 	base := driveBasePath(*drive)
@@ -153,71 +157,41 @@ func runDriveList(rt *runtimeState, id identityContext, args []string) int {
 	}
 
 	q := url.Values{}
-	q.Set("$top", strconv.Itoa(*max))
+	list.apply(q)
 	q.Set("$orderby", "name")
 	q.Set("$select", "id,name,size,createdDateTime,lastModifiedDateTime,webUrl,file,folder,parentReference,deleted")
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
-
-	var resp struct {
-		Value []map[string]any `json:"value"`
-	}
-	next, err := rt.graphRequest(id, http.MethodGet, path, q, nil, &resp)
-	if err != nil {
-		return rt.failErr(err)
-	}
 
-	if rt.globals.Plain {
-		for _, it := range resp.Value {
-			_, _ = fmt.Fprintf(rt.stdout, "%s\t%s\t%s\t%d\n", asString(it["id"]), driveKind(it), strings.ReplaceAll(asString(it["name"]), "\t", " "), asInt64(it["size"]))
-		}
-		if next != "" {
-			_, _ = fmt.Fprintf(rt.stdout, "next_page\t%s\n", next)
-		}
-		return exitcode.Success
-	}
-
-	return rt.writeJSON(map[string]any{"items": resp.Value, "next_page": next, "drive": strings.TrimSpace(*drive)})
+	return rt.writeList(id, path, q, *list, func(it map[string]any) string {
+		return fmt.Sprintf("%s\t%s\t%s\t%d", asString(it["id"]), driveKind(it), strings.ReplaceAll(asString(it["name"]), "\t", " "), asInt64(it["size"]))
+	}, map[string]any{"drive": strings.TrimSpace(*drive)})
 }
 
 func runDriveSearch(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("drive search", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	max := fs.Int("max", 100, "Max items")
-	page := fs.String("page", "", "Page token")
+	list := addListFlags(fs, 100)
 	drive := fs.String("drive", "", "Drive container id")
 	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
-		return rt.failErr(usageError("invalid drive search flags", "Usage: mo drive search <text> [--max N] [--page TOKEN] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("invalid drive search flags", "Usage: mo drive search <text> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]"))
 	}
 	if fs.NArg() != 1 {
-		return rt.failErr(usageError("search text is required", "Usage: mo drive search <text> [--max N] [--page TOKEN] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("search text is required", "Usage: mo drive search <text> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]"))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
 	text := strings.TrimSpace(fs.Arg(0))
 	if text == "" {
-		return rt.failErr(usageError("search text is required", "Usage: mo drive search <text> [--max N] [--page TOKEN] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("search text is required", "Usage: mo drive search <text> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]"))
 	}
 
 	base := driveBasePath(*drive)
 	path := base + "/root/search(q='" + url.PathEscape(strings.ReplaceAll(text, "'", "''")) + "')"
 	q := url.Values{}
-	q.Set("$top", strconv.Itoa(*max))
+	list.apply(q)
 	q.Set("$select", "id,name,size,createdDateTime,lastModifiedDateTime,webUrl,file,folder,parentReference,deleted")
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
 
-	var resp struct {
-		Value []map[string]any `json:"value"`
-	}
-	next, err := rt.graphRequest(id, http.MethodGet, path, q, nil, &resp)
-	if err != nil {
-		return rt.failErr(err)
-	}
-	return rt.writeJSON(map[string]any{"items": resp.Value, "next_page": next, "query": text, "drive": strings.TrimSpace(*drive)})
+	return rt.writeList(id, path, q, *list, nil, map[string]any{"query": text, "drive": strings.TrimSpace(*drive)})
 }
 
 func runDriveGet(rt *runtimeState, id identityContext, args []string) int {
@@ -251,21 +225,27 @@ func runDriveUpload(rt *runtimeState, id identityContext, args []string) int {
 	parent := fs.String("parent", "", "Parent folder item id")
 	name := fs.String("name", "", "Uploaded file name")
 	conflict := fs.String("conflict", "fail", "Conflict behavior: fail|rename|replace")
+	chunkSize := fs.String("chunk-size", "", "Upload session chunk size (e.g. 10MiB)")
+	resume := fs.Bool("resume", false, "Resume a saved upload session")
 	drive := fs.String("drive", "", "Drive container id")
 	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
-		return rt.failErr(usageError("invalid drive upload flags", "Usage: mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("invalid drive upload flags", "Usage: mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--chunk-size SIZE] [--resume] [--drive DRIVE_ID]"))
 	}
 	if fs.NArg() != 1 {
-		return rt.failErr(usageError("local path is required", "Usage: mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("local path is required", "Usage: mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--chunk-size SIZE] [--resume] [--drive DRIVE_ID]"))
 	}
 	localPath := strings.TrimSpace(fs.Arg(0))
 	if localPath == "" {
-		return rt.failErr(usageError("local path is required", "Usage: mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("local path is required", "Usage: mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--chunk-size SIZE] [--resume] [--drive DRIVE_ID]"))
 	}
 	behavior := strings.ToLower(strings.TrimSpace(*conflict))
 	if behavior != "fail" && behavior != "rename" && behavior != "replace" {
 		return rt.failErr(usageError("invalid --conflict", "Allowed values: fail, rename, replace"))
 	}
+	chunkBytes, err := parseChunkSize(*chunkSize)
+	if err != nil {
+		return rt.failErr(usageError("invalid --chunk-size", err.Error()+". Use a multiple of 320KiB up to 60MiB, e.g. 10MiB."))
+	}
 	info, err := os.Stat(localPath)
 	if err != nil {
 		return rt.failErr(usageError("failed to read local file", err.Error()))
@@ -277,10 +257,6 @@ func runDriveUpload(rt *runtimeState, id identityContext, args []string) int {
 	if size < 0 {
 		return rt.failErr(usageError("invalid local file size", "Unable to determine upload size."))
 	}
-	if size > driveSmallUploadLimitBytes {
-		return rt.failErr(usageError("file too large for simple upload", "Limit is 250MB for this command. Resumable upload is planned."))
-	}
-
 	resolvedName := strings.TrimSpace(*name)
 	if resolvedName == "" {
 		resolvedName = filepath.Base(localPath)
@@ -288,6 +264,9 @@ func runDriveUpload(rt *runtimeState, id identityContext, args []string) int {
 	if resolvedName == "" || resolvedName == "." || resolvedName == string(os.PathSeparator) {
 		return rt.failErr(usageError("invalid upload name", "Provide --name for this path."))
 	}
+	if size > driveSmallUploadLimitBytes || *resume || strings.TrimSpace(*chunkSize) != "" {
+		return runDriveUploadSession(rt, id, localPath, info, *drive, *parent, resolvedName, behavior, chunkBytes, *resume)
+	}
 
 	base := driveBasePath(*drive)
 	createPath := base + "/root/children"
@@ -316,7 +295,7 @@ func runDriveUpload(rt *runtimeState, id identityContext, args []string) int {
 	defer f.Close()
 
 	uploadPath := base + "/items/" + url.PathEscape(createdID) + "/content"
-	rawResp, err := rt.driveRawRequest(id, http.MethodPut, uploadPath, nil, f, "application/octet-stream", size)
+	rawResp, err := rt.driveRawRequest(id, http.MethodPut, uploadPath, nil, nil, f, "application/octet-stream", size)
 	if err != nil {
 		return rt.failErr(err)
 	}
@@ -356,12 +335,15 @@ func runDriveDownload(rt *runtimeState, id identityContext, args []string) int {
 		return rt.failErr(usageError("item id is required", "Usage: mo drive download <item-id> [--out PATH] [--drive DRIVE_ID]"))
 	}
 
+	q := url.Values{}
+	q.Set("$select", "id,name,size,file,eTag")
 	var meta map[string]any
-	_, err := rt.graphRequest(id, http.MethodGet, driveItemPath(*drive, itemID), nil, nil, &meta)
+	_, err := rt.graphRequest(id, http.MethodGet, driveItemPath(*drive, itemID), q, nil, &meta)
 	if err != nil {
 		return rt.failErr(err)
 	}
 	name := asString(meta["name"])
+	expectedSize := asInt64(meta["size"])
 	dest, err := resolveDriveDownloadPath(strings.TrimSpace(*outPath), name, itemID)
 	if err != nil {
 		return rt.failErr(usageError("invalid --out path", err.Error()))
@@ -370,37 +352,108 @@ func runDriveDownload(rt *runtimeState, id identityContext, args []string) int {
 		return rt.failErr(transientError("failed to create output directory", mkErr.Error()))
 	}
 
-	rawResp, err := rt.driveRawRequest(id, http.MethodGet, driveItemPath(*drive, itemID)+"/content", nil, nil, "", -1)
+	// Partial data is kept in dest.part between runs so an interrupted
+	// download resumes with a Range request instead of starting over.
+	tmp := dest + ".part"
+	offset := int64(0)
+	if st, err := os.Stat(tmp); err == nil && st.Mode().IsRegular() {
+		offset = st.Size()
+	}
+	if expectedSize > 0 && offset > expectedSize {
+		offset = 0
+	}
+	resumed := offset > 0
+
+	transferred := int64(0)
+	if expectedSize <= 0 || offset < expectedSize {
+		n, partial, err := rt.downloadDriveContent(id, driveItemPath(*drive, itemID)+"/content", tmp, offset, asString(meta["eTag"]))
+		if err != nil {
+			return rt.failErr(err)
+		}
+		transferred, resumed = n, partial
+	}
+
+	st, err := os.Stat(tmp)
 	if err != nil {
+		return rt.failErr(transientError("failed to finalize output file", err.Error()))
+	}
+	if expectedSize > 0 && st.Size() != expectedSize {
+		return rt.failErr(transientError(
+			"download incomplete",
+			fmt.Sprintf("Received %d of %d bytes. Re-run the same command to resume.", st.Size(), expectedSize),
+		))
+	}
+
+	hashInfo, err := verifyDriveDownload(tmp, meta)
+	if err != nil {
+		_ = os.Remove(tmp)
 		return rt.failErr(err)
 	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return rt.failErr(transientError("failed to place output file", err.Error()))
+	}
+
+	out := map[string]any{
+		"downloaded":        true,
+		"id":                itemID,
+		"path":              dest,
+		"bytes":             st.Size(),
+		"bytes_transferred": transferred,
+		"resumed":           resumed,
+	}
+	if hashInfo != nil {
+		out["hash"] = hashInfo
+	}
+	return rt.writeJSON(out)
+}
+
+// downloadDriveContent appends item content to partPath starting at offset and
+// returns the number of bytes received and whether the part was resumed. The
+// Range request carries If-Range with the item's eTag, so a changed item, or
+// a server that ignores Range, restarts the file from zero.
+func (rt *runtimeState) downloadDriveContent(id identityContext, contentPath, partPath string, offset int64, etag string) (int64, bool, error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if etag != "" {
+			header.Set("If-Range", etag)
+		}
+	}
+	rawResp, err := rt.driveRawRequest(id, http.MethodGet, contentPath, nil, header, nil, "", -1)
+	if err != nil {
+		return 0, false, err
+	}
 	defer rawResp.Body.Close()
-	if rawResp.StatusCode < 200 || rawResp.StatusCode >= 300 {
+
+	flags := os.O_CREATE | os.O_WRONLY
+	resumed := false
+	switch {
+	case rawResp.StatusCode == http.StatusPartialContent:
+		flags |= os.O_APPEND
+		resumed = true
+	case rawResp.StatusCode >= 200 && rawResp.StatusCode < 300:
+		flags |= os.O_TRUNC
+	case rawResp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Nothing left to fetch; size and hash checks decide whether the part is usable.
+		return 0, true, nil
+	default:
 		body, _ := io.ReadAll(io.LimitReader(rawResp.Body, 4<<20))
-		return rt.failErr(graphErrorFromBody(rawResp.StatusCode, body))
+		return 0, false, graphErrorFromBody(rawResp.StatusCode, body)
 	}
 
-	tmp := dest + ".part"
-	f, err := os.Create(tmp)
+	f, err := os.OpenFile(partPath, flags, 0o644)
 	if err != nil {
-		return rt.failErr(transientError("failed to create output file", err.Error()))
+		return 0, false, transientError("failed to create output file", err.Error())
 	}
 	written, copyErr := io.Copy(f, rawResp.Body)
 	closeErr := f.Close()
 	if copyErr != nil {
-		_ = os.Remove(tmp)
-		return rt.failErr(transientError("download failed", copyErr.Error()))
+		return written, resumed, transientError("download interrupted", fmt.Sprintf("%v. Partial data kept in %s; re-run the same command to resume.", copyErr, partPath))
 	}
 	if closeErr != nil {
-		_ = os.Remove(tmp)
-		return rt.failErr(transientError("failed to finalize output file", closeErr.Error()))
+		return written, resumed, transientError("failed to finalize output file", closeErr.Error())
 	}
-	if err := os.Rename(tmp, dest); err != nil {
-		_ = os.Remove(tmp)
-		return rt.failErr(transientError("failed to place output file", err.Error()))
-	}
-
-	return rt.writeJSON(map[string]any{"downloaded": true, "id": itemID, "path": dest, "bytes": written})
+	return written, resumed, nil
 }
 
 func runDriveMkdir(rt *runtimeState, id identityContext, args []string) int {
@@ -531,36 +584,25 @@ func runDriveDelete(rt *runtimeState, id identityContext, args []string) int {
 func runDrivePermissions(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("drive permissions", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	max := fs.Int("max", 100, "Max permissions")
-	page := fs.String("page", "", "Page token")
+	list := addListFlags(fs, 100)
 	drive := fs.String("drive", "", "Drive container id")
 	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
-		return rt.failErr(usageError("invalid drive permissions flags", "Usage: mo drive permissions <item-id> [--max N] [--page TOKEN] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("invalid drive permissions flags", "Usage: mo drive permissions <item-id> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]"))
 	}
 	if fs.NArg() != 1 {
-		return rt.failErr(usageError("item id is required", "Usage: mo drive permissions <item-id> [--max N] [--page TOKEN] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("item id is required", "Usage: mo drive permissions <item-id> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]"))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
 	itemID := strings.TrimSpace(fs.Arg(0))
 	if itemID == "" {
-		return rt.failErr(usageError("item id is required", "Usage: mo drive permissions <item-id> [--max N] [--page TOKEN] [--drive DRIVE_ID]"))
+		return rt.failErr(usageError("item id is required", "Usage: mo drive permissions <item-id> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]"))
 	}
 
 	q := url.Values{}
-	q.Set("$top", strconv.Itoa(*max))
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
-	var out struct {
-		Value []map[string]any `json:"value"`
-	}
-	next, err := rt.graphRequest(id, http.MethodGet, driveItemPath(*drive, itemID)+"/permissions", q, nil, &out)
-	if err != nil {
-		return rt.failErr(err)
-	}
-	return rt.writeJSON(map[string]any{"item_id": itemID, "items": out.Value, "next_page": next})
+	list.apply(q)
+	return rt.writeList(id, driveItemPath(*drive, itemID)+"/permissions", q, *list, nil, map[string]any{"item_id": itemID})
 }
 
 func runDriveShare(rt *runtimeState, id identityContext, args []string) int {
@@ -755,64 +797,40 @@ func runDriveCommentDelete(rt *runtimeState, _ identityContext, args []string) i
 func runDriveDrives(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("drive drives", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	max := fs.Int("max", 100, "Max drives")
-	page := fs.String("page", "", "Page token")
+	list := addListFlags(fs, 100)
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid drive drives flags", "Usage: mo drive drives [--max N] [--page TOKEN]"))
+		return rt.failErr(usageError("invalid drive drives flags", "Usage: mo drive drives [--max N] [--page TOKEN] [--all [--limit N]]"))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("drive drives does not take positional arguments", "Run 'mo drive drives --help'."))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
 
 	q := url.Values{}
-	q.Set("$top", strconv.Itoa(*max))
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
-	var out struct {
-		Value []map[string]any `json:"value"`
-	}
-	next, err := rt.graphRequest(id, http.MethodGet, "/v1.0/me/drives", q, nil, &out)
-	if err != nil {
-		return rt.failErr(err)
-	}
-	return rt.writeJSON(map[string]any{"items": out.Value, "next_page": next})
+	list.apply(q)
+	return rt.writeList(id, "/v1.0/me/drives", q, *list, nil, nil)
 }
 
 func runDriveShared(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("drive shared", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	max := fs.Int("max", 100, "Max shared items")
-	page := fs.String("page", "", "Page token")
+	list := addListFlags(fs, 100)
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid drive shared flags", "Usage: mo drive shared [--max N] [--page TOKEN]"))
+		return rt.failErr(usageError("invalid drive shared flags", "Usage: mo drive shared [--max N] [--page TOKEN] [--all [--limit N]]"))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("drive shared does not take positional arguments", "Run 'mo drive shared --help'."))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
 
 	q := url.Values{}
-	q.Set("$top", strconv.Itoa(*max))
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
-	var out struct {
-		Value []map[string]any `json:"value"`
-	}
-	next, err := rt.graphRequest(id, http.MethodGet, "/v1.0/me/drive/sharedWithMe", q, nil, &out)
-	if err != nil {
-		return rt.failErr(err)
-	}
-	return rt.writeJSON(map[string]any{
-		"items":     out.Value,
-		"next_page": next,
-		"warning":   "Microsoft Graph sharedWithMe is deprecated (announced 2025-05-08) and may degrade until retirement.",
+	list.apply(q)
+	return rt.writeList(id, "/v1.0/me/drive/sharedWithMe", q, *list, nil, map[string]any{
+		"warning": "Microsoft Graph sharedWithMe is deprecated (announced 2025-05-08) and may degrade until retirement.",
 	})
 }
 
@@ -876,6 +894,43 @@ func resolveDriveDownloadPath(outPath, itemName, itemID string) (string, error)
 	return outPath, nil
 }
 
+// verifyDriveDownload checks the downloaded file against the item's
+// file.hashes, preferring sha256Hash over quickXorHash when both exist.
+func verifyDriveDownload(path string, meta map[string]any) (map[string]any, error) {
+	file, _ := meta["file"].(map[string]any)
+	hashes, _ := file["hashes"].(map[string]any)
+	algorithm := ""
+	expected := ""
+	var h hash.Hash
+	encode := func(sum []byte) string { return strings.ToUpper(hex.EncodeToString(sum)) }
+	if v := asString(hashes["sha256Hash"]); v != "" {
+		algorithm, expected, h = "sha256Hash", strings.ToUpper(v), sha256.New()
+	} else if v := asString(hashes["quickXorHash"]); v != "" {
+		algorithm, expected, h = "quickXorHash", v, quickxor.New()
+		encode = base64.StdEncoding.EncodeToString
+	}
+	if h == nil {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, transientError("failed to read downloaded file", err.Error())
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, transientError("failed to hash downloaded file", err.Error())
+	}
+	actual := encode(h.Sum(nil))
+	if actual != expected {
+		return nil, transientError(
+			"download integrity check failed",
+			fmt.Sprintf("%s mismatch (expected %s, got %s). Partial data was discarded; re-run to download again.", algorithm, expected, actual),
+		)
+	}
+	return map[string]any{"algorithm": algorithm, "value": actual, "verified": true}, nil
+}
+
 func sanitizeDriveName(v string) string {
 	name := strings.TrimSpace(v)
 	name = strings.ReplaceAll(name, "\\", "/")
@@ -887,7 +942,13 @@ func sanitizeDriveName(v string) string {
 	return name
 }
 
-func (rt *runtimeState) driveRawRequest(id identityContext, method, path string, query url.Values, body io.Reader, contentType string, contentLength int64) (*http.Response, error) {
+func (rt *runtimeState) driveRawRequest(id identityContext, method, path string, query url.Values, header http.Header, body io.Reader, contentType string, contentLength int64) (*http.Response, error) {
+	return rt.driveRawRequestContext(context.Background(), id, method, path, query, header, body, contentType, contentLength)
+}
+
+// driveRawRequestContext is driveRawRequest bound to ctx, which also ends
+// reading the response body.
+func (rt *runtimeState) driveRawRequestContext(ctx context.Context, id identityContext, method, path string, query url.Values, header http.Header, body io.Reader, contentType string, contentLength int64) (*http.Response, error) {
 	accessToken, err := rt.accessToken(id)
 	if err != nil {
 		return nil, err
@@ -898,25 +959,45 @@ func (rt *runtimeState) driveRawRequest(id identityContext, method, path string,
 		u += "?" + query.Encode()
 	}
 
-	req, err := http.NewRequest(method, u, body)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/json")
-	if strings.TrimSpace(contentType) != "" {
-		req.Header.Set("Content-Type", contentType)
-	}
-	if contentLength >= 0 {
-		req.ContentLength = contentLength
-	}
+	// No overall timeout: bodies can be gigabytes. Stalled servers are still
+	// bounded by the response header timeout.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 60 * time.Second
+	httpClient := &http.Client{Transport: transport}
 
-	httpClient := &http.Client{Timeout: 60 * time.Second}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, transientError("graph request failed", err.Error())
+	for reauthorized := false; ; reauthorized = true {
+		req, err := http.NewRequestWithContext(ctx, method, u, body)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Accept", "application/json")
+		if strings.TrimSpace(contentType) != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if contentLength >= 0 {
+			req.ContentLength = contentLength
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, transientError("graph request failed", err.Error())
+		}
+		// A streamed body cannot be replayed, so only bodiless requests get
+		// a second attempt with a freshly refreshed token.
+		if resp.StatusCode != http.StatusUnauthorized || body != nil || reauthorized {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		_ = resp.Body.Close()
+		rt.invalidateAccessToken(id)
+		if accessToken, err = rt.refreshAccessToken(id); err != nil {
+			return nil, err
+		}
 	}
-	return resp, nil
 }
 
 func graphErrorFromBody(status int, body []byte) error {
@@ -1,6 +1,14 @@
 package app
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestDriveBasePathDefault(t *testing.T) {
 	got := driveBasePath("")
@@ -57,3 +65,79 @@ func TestDriveKind(t *testing.T) {
 		t.Fatalf("driveKind(item) = %q", got)
 	}
 }
+
+func TestVerifyDriveDownloadSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.part")
+	if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	meta := map[string]any{"file": map[string]any{"hashes": map[string]any{
+		"sha256Hash":   "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		"quickXorHash": "ignored",
+	}}}
+	info, err := verifyDriveDownload(path, meta)
+	if err != nil {
+		t.Fatalf("verifyDriveDownload returned error: %v", err)
+	}
+	if info["algorithm"] != "sha256Hash" || info["verified"] != true {
+		t.Fatalf("unexpected hash info %v", info)
+	}
+}
+
+func TestVerifyDriveDownloadMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.part")
+	if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	meta := map[string]any{"file": map[string]any{"hashes": map[string]any{"quickXorHash": "AAAAAAAAAAAAAAAAAAAAAAAAAAA="}}}
+	if _, err := verifyDriveDownload(path, meta); err == nil {
+		t.Fatalf("expected integrity error")
+	}
+}
+
+func TestVerifyDriveDownloadWithoutHashes(t *testing.T) {
+	info, err := verifyDriveDownload("missing", map[string]any{"file": map[string]any{}})
+	if err != nil || info != nil {
+		t.Fatalf("expected no verification without hashes, got %v, %v", info, err)
+	}
+}
+
+func TestDriveDownloadRestartsWhenRangeIsIgnored(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(dest+".part", []byte("old"), 0o600); err != nil {
+		t.Fatalf("write part: %v", err)
+	}
+	var ifRange string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.0/me/drive/items/item-1":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"item-1","name":"report.txt","size":5,"eTag":"\"{E1},2\""}`))
+		case "/v1.0/me/drive/items/item-1/content":
+			// The item changed since the part was written, so If-Range
+			// does not match and the whole file comes back.
+			ifRange = r.Header.Get("If-Range")
+			_, _ = w.Write([]byte("hello"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runDriveDownload(rt, identityContext{Account: "me@contoso.com"}, []string{"item-1", "--out", dest}); code != 0 {
+		t.Fatalf("exit %d: %s %s", code, out.String(), rt.stderr)
+	}
+	if ifRange != `"{E1},2"` {
+		t.Fatalf("expected If-Range with the item eTag, got %q", ifRange)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil || got["resumed"] != false || got["bytes"] != float64(5) {
+		t.Fatalf("unexpected output %q: %v", out.String(), err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "hello" {
+		t.Fatalf("expected a fresh file, got %q", data)
+	}
+}
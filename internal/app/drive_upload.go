@@ -0,0 +1,436 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/svaruag/mocli/internal/config"
+)
+
+const (
+	// Upload session chunks must be multiples of 320 KiB and at most 60 MiB.
+	uploadChunkUnit         = 320 * 1024
+	uploadChunkMax          = 60 * 1024 * 1024
+	defaultUploadChunkBytes = 32 * uploadChunkUnit
+	uploadChunkAttempts     = 5
+	// uploadRealignAttempts caps how often a 416 may move the upload to the
+	// session's own offset before giving up.
+	uploadRealignAttempts = 5
+)
+
+type driveUploadSession struct {
+	UploadURL  string `json:"upload_url"`
+	Expiration string `json:"expiration,omitempty"`
+	LocalPath  string `json:"local_path"`
+	Size       int64  `json:"size"`
+	ModTime    string `json:"mod_time"`
+	Drive      string `json:"drive,omitempty"`
+	Parent     string `json:"parent,omitempty"`
+	Name       string `json:"name"`
+	Conflict   string `json:"conflict"`
+	ChunkSize  int64  `json:"chunk_size"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type uploadSessionStatus struct {
+	UploadURL          string   `json:"uploadUrl"`
+	ExpirationDateTime string   `json:"expirationDateTime"`
+	NextExpectedRanges []string `json:"nextExpectedRanges"`
+}
+
+func runDriveUploadSession(rt *runtimeState, id identityContext, localPath string, info os.FileInfo, drive, parent, name, behavior string, chunkSize int64, resume bool) int {
+	statePath, err := driveUploadStatePath(id, drive, parent, name, localPath)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	size := info.Size()
+	modTime := info.ModTime().UTC().Format(time.RFC3339Nano)
+
+	var session driveUploadSession
+	resumed := false
+	if resume {
+		saved, err := loadDriveUploadSession(statePath)
+		switch {
+		case err == nil:
+			if saved.Size != size || saved.ModTime != modTime {
+				return rt.failErr(usageError("local file changed since upload session started", "Re-run without --resume to start a new upload."))
+			}
+			session = saved
+			resumed = true
+		case errors.Is(err, os.ErrNotExist):
+		default:
+			return rt.failErr(err)
+		}
+	} else if stale, err := loadDriveUploadSession(statePath); err == nil {
+		cancelUploadSession(stale.UploadURL)
+	}
+
+	if !resumed {
+		status, err := createDriveUploadSession(rt, id, drive, parent, name, behavior)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		session = driveUploadSession{
+			UploadURL:  status.UploadURL,
+			Expiration: status.ExpirationDateTime,
+			LocalPath:  localPath,
+			Size:       size,
+			ModTime:    modTime,
+			Drive:      strings.TrimSpace(drive),
+			Parent:     strings.TrimSpace(parent),
+			Name:       name,
+			Conflict:   behavior,
+			ChunkSize:  chunkSize,
+			CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		}
+		if err := saveDriveUploadSession(statePath, session); err != nil {
+			return rt.failErr(err)
+		}
+	}
+
+	// Ctrl-C stops between chunks and retries and keeps the session for
+	// --resume.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	offset := int64(0)
+	if resumed {
+		offset, err = queryUploadSessionOffset(ctx, session.UploadURL, size)
+		if err != nil {
+			if isNotFoundErr(err) {
+				_ = os.Remove(statePath)
+				return rt.failErr(notFoundError("upload session expired", "Re-run without --resume to start a new upload."))
+			}
+			return rt.failErr(err)
+		}
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return rt.failErr(usageError("failed to open local file", err.Error()))
+	}
+	defer f.Close()
+
+	body, err := putUploadSessionChunks(ctx, session.UploadURL, f, size, offset, chunkSize)
+	if err != nil {
+		if isNotFoundErr(err) {
+			_ = os.Remove(statePath)
+			return rt.failErr(notFoundError("upload session expired", "Re-run without --resume to start a new upload."))
+		}
+		var ae *appError
+		if errors.As(err, &ae) && ae.Code == "transient_error" {
+			ae.Hint = strings.TrimSpace(ae.Hint + " Re-run with --resume to continue the upload.")
+		}
+		return rt.failErr(err)
+	}
+	_ = os.Remove(statePath)
+
+	out := map[string]any{}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return rt.failErr(transientError("upload completed but response parse failed", err.Error()))
+	}
+	out["local_path"] = localPath
+	out["conflict"] = behavior
+	out["chunk_size"] = chunkSize
+	out["resumed"] = resumed
+	return rt.writeJSON(out)
+}
+
+// driveItemPathByName addresses name inside parent, or inside the drive root
+// without one.
+func driveItemPathByName(drive, parent, name string) string {
+	base := driveBasePath(drive)
+	if strings.TrimSpace(parent) != "" {
+		return base + "/items/" + url.PathEscape(strings.TrimSpace(parent)) + ":/" + url.PathEscape(name) + ":"
+	}
+	return base + "/root:/" + url.PathEscape(name) + ":"
+}
+
+func createDriveUploadSession(rt *runtimeState, id identityContext, drive, parent, name, behavior string) (uploadSessionStatus, error) {
+	path := driveItemPathByName(drive, parent, name) + "/createUploadSession"
+	payload := map[string]any{
+		"item": map[string]any{
+			"@microsoft.graph.conflictBehavior": behavior,
+			"name":                              name,
+		},
+	}
+	var status uploadSessionStatus
+	if _, err := rt.graphRequest(id, http.MethodPost, path, nil, payload, &status); err != nil {
+		return uploadSessionStatus{}, err
+	}
+	if strings.TrimSpace(status.UploadURL) == "" {
+		return uploadSessionStatus{}, transientError("upload session initialization failed", "graph response missing uploadUrl")
+	}
+	return status, nil
+}
+
+// putUploadSessionChunks sends [offset, size) to a Graph upload session and
+// returns the final 200/201 response body, which describes the item. Upload URLs are pre-authenticated, so no
+// bearer token is attached. It stops between chunks and retries once ctx is
+// done.
+func putUploadSessionChunks(ctx context.Context, uploadURL string, f io.ReaderAt, size, offset, chunkSize int64) ([]byte, error) {
+	httpClient := &http.Client{Timeout: 5 * time.Minute}
+	buf := make([]byte, chunkSize)
+	realigns := 0
+	for offset < size {
+		if err := ctx.Err(); err != nil {
+			return nil, transientError("upload interrupted", err.Error())
+		}
+		end := offset + chunkSize
+		if end > size {
+			end = size
+		}
+		chunk := buf[:end-offset]
+		if _, err := f.ReadAt(chunk, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, usageError("failed to read local file", err.Error())
+		}
+
+		var status int
+		var body []byte
+		for attempt := 1; attempt <= uploadChunkAttempts; attempt++ {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, bytes.NewReader(chunk))
+			if err != nil {
+				return nil, fmt.Errorf("create request: %w", err)
+			}
+			req.ContentLength = int64(len(chunk))
+			req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, end-1, size))
+
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				if attempt == uploadChunkAttempts || ctx.Err() != nil {
+					return nil, transientError("upload chunk failed", err.Error())
+				}
+				if err := sleepContext(ctx, backoffDuration(attempt)); err != nil {
+					return nil, transientError("upload interrupted", err.Error())
+				}
+				continue
+			}
+			body, _ = io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+			_ = resp.Body.Close()
+			status = resp.StatusCode
+			if shouldRetryStatus(status) && attempt < uploadChunkAttempts {
+				if err := sleepContext(ctx, retryDelay(resp, attempt)); err != nil {
+					return nil, transientError("upload interrupted", err.Error())
+				}
+				continue
+			}
+			break
+		}
+
+		// OneDrive acknowledges intermediate chunks with 202; Outlook
+		// attachment sessions use 200 and include nextExpectedRanges.
+		var st uploadSessionStatus
+		_ = json.Unmarshal(body, &st)
+		inProgress := status == http.StatusAccepted || (status == http.StatusOK && len(st.NextExpectedRanges) > 0)
+		switch {
+		case inProgress:
+			next, ok := nextExpectedOffset(st.NextExpectedRanges)
+			if !ok {
+				next = end
+			}
+			offset = next
+		case status == http.StatusOK || status == http.StatusCreated:
+			return body, nil
+		case status == http.StatusRequestedRangeNotSatisfiable:
+			// The server already has (part of) this range; realign with its
+			// view, which has to be further along or the same range would be
+			// refused again.
+			realigns++
+			if realigns > uploadRealignAttempts {
+				return nil, transientError("upload session kept rejecting chunk ranges", "Re-run without --resume to start a new upload.")
+			}
+			next, err := queryUploadSessionOffset(ctx, uploadURL, size)
+			if err != nil {
+				return nil, err
+			}
+			if next <= offset {
+				return nil, transientError("upload session offset did not advance", fmt.Sprintf("Graph rejected bytes %d-%d but still expects byte %d; re-run without --resume to start a new upload.", offset, end-1, next))
+			}
+			offset = next
+		default:
+			return nil, graphErrorFromBody(status, body)
+		}
+	}
+	// Every byte is on the server but no item came back, e.g. when a resumed
+	// session had already received the last chunk.
+	return commitUploadSession(ctx, uploadURL)
+}
+
+// queryUploadSessionOffset returns the first byte the session still expects.
+// A session without missing ranges has all size bytes but no item yet.
+func queryUploadSessionOffset(ctx context.Context, uploadURL string, size int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uploadURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, transientError("upload session status request failed", err.Error())
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, graphErrorFromBody(resp.StatusCode, body)
+	}
+	var st uploadSessionStatus
+	if err := json.Unmarshal(body, &st); err != nil {
+		return 0, transientError("upload session status parse failed", err.Error())
+	}
+	if len(st.NextExpectedRanges) == 0 {
+		return size, nil
+	}
+	next, ok := nextExpectedOffset(st.NextExpectedRanges)
+	if !ok {
+		return 0, transientError("upload session status parse failed", fmt.Sprintf("unexpected nextExpectedRanges %q", st.NextExpectedRanges))
+	}
+	return next, nil
+}
+
+// commitUploadSession asks the session to create the item from the bytes it
+// already has, which Graph does for an empty POST to the upload URL. A 404
+// means the session expired; the item is only known from a 200/201 body.
+func commitUploadSession(ctx context.Context, uploadURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.ContentLength = 0
+	httpClient := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, transientError("upload session commit failed", err.Error())
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return body, nil
+	default:
+		return nil, graphErrorFromBody(resp.StatusCode, body)
+	}
+}
+
+func cancelUploadSession(uploadURL string) {
+	req, err := http.NewRequest(http.MethodDelete, uploadURL, nil)
+	if err != nil {
+		return
+	}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	if resp, err := httpClient.Do(req); err == nil {
+		_ = resp.Body.Close()
+	}
+}
+
+// nextExpectedOffset returns the start of the first missing range reported by
+// Graph, e.g. "26214400-" or "0-1023".
+func nextExpectedOffset(ranges []string) (int64, bool) {
+	if len(ranges) == 0 {
+		return 0, false
+	}
+	start, _, _ := strings.Cut(strings.TrimSpace(ranges[0]), "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseChunkSize accepts a byte count with an optional KiB/MiB suffix and
+// rounds it down to the 320 KiB granularity required by Graph.
+func parseChunkSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	if s == "" {
+		return defaultUploadChunkBytes, nil
+	}
+	mult := int64(1)
+	for _, suffix := range []struct {
+		text string
+		mult int64
+	}{
+		{"MIB", 1 << 20}, {"MB", 1 << 20}, {"M", 1 << 20},
+		{"KIB", 1 << 10}, {"KB", 1 << 10}, {"K", 1 << 10},
+	} {
+		if strings.HasSuffix(s, suffix.text) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, suffix.text))
+			mult = suffix.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid chunk size %q", raw)
+	}
+	size := (n * mult) / uploadChunkUnit * uploadChunkUnit
+	if size == 0 {
+		return 0, fmt.Errorf("chunk size must be at least 320KiB")
+	}
+	if size > uploadChunkMax {
+		return 0, fmt.Errorf("chunk size must be at most 60MiB")
+	}
+	return size, nil
+}
+
+func driveUploadStatePath(id identityContext, drive, parent, name, localPath string) (string, error) {
+	dir, err := config.UploadStateDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(localPath)
+	if err != nil {
+		abs = localPath
+	}
+	key := strings.Join([]string{id.Client, id.Account, strings.TrimSpace(drive), strings.TrimSpace(parent), name, abs}, "\x00")
+	digest := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, hex.EncodeToString(digest[:16])+".json"), nil
+}
+
+func loadDriveUploadSession(path string) (driveUploadSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return driveUploadSession{}, err
+	}
+	var s driveUploadSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return driveUploadSession{}, fmt.Errorf("parse upload session: %w", err)
+	}
+	if strings.TrimSpace(s.UploadURL) == "" {
+		return driveUploadSession{}, os.ErrNotExist
+	}
+	return s, nil
+}
+
+func saveDriveUploadSession(path string, s driveUploadSession) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("ensure upload state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal upload session: %w", err)
+	}
+	if err := config.WriteFileAtomic(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write upload session: %w", err)
+	}
+	return nil
+}
+
+func isNotFoundErr(err error) bool {
+	var ae *appError
+	return errors.As(err, &ae) && ae.Code == "not_found"
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseChunkSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "", want: defaultUploadChunkBytes},
+		{in: "10MiB", want: 10 * 1024 * 1024},
+		{in: "320k", want: 320 * 1024},
+		{in: "1MiB", want: 3 * 320 * 1024},
+		{in: "100KiB", wantErr: true},
+		{in: "61MiB", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseChunkSize(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Fatalf("parseChunkSize(%q) expected error, got %d", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("parseChunkSize(%q) returned error: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Fatalf("parseChunkSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNextExpectedOffset(t *testing.T) {
+	if got, ok := nextExpectedOffset([]string{"26214400-"}); !ok || got != 26214400 {
+		t.Fatalf("nextExpectedOffset open range = %d,%v", got, ok)
+	}
+	if got, ok := nextExpectedOffset([]string{"5-9", "20-"}); !ok || got != 5 {
+		t.Fatalf("nextExpectedOffset multi range = %d,%v", got, ok)
+	}
+	if _, ok := nextExpectedOffset(nil); ok {
+		t.Fatalf("expected no offset for empty ranges")
+	}
+}
+
+func TestPutUploadSessionChunksRetriesFailedRange(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 25)
+	var received bytes.Buffer
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("upload session requests must not carry a bearer token")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasPrefix(r.Header.Get("Content-Range"), "bytes 10-") && failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received.Write(body)
+		if received.Len() == len(data) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"id":"item-1"}`)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = fmt.Fprintf(w, `{"nextExpectedRanges":["%d-"]}`, received.Len())
+	}))
+	defer srv.Close()
+
+	body, err := putUploadSessionChunks(context.Background(), srv.URL, bytes.NewReader(data), int64(len(data)), 0, 10)
+	if err != nil {
+		t.Fatalf("putUploadSessionChunks returned error: %v", err)
+	}
+	if !strings.Contains(string(body), "item-1") {
+		t.Fatalf("unexpected final body %q", body)
+	}
+	if !bytes.Equal(received.Bytes(), data) {
+		t.Fatalf("server received %q, want %q", received.Bytes(), data)
+	}
+}
+
+func TestPutUploadSessionChunksCommitsWhenServerHasAllBytes(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 20)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		case http.MethodGet:
+			_, _ = io.WriteString(w, `{"nextExpectedRanges":[]}`)
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{"id":"item-1"}`)
+		}
+	}))
+	defer srv.Close()
+
+	body, err := putUploadSessionChunks(context.Background(), srv.URL, bytes.NewReader(data), int64(len(data)), 10, 10)
+	if err != nil || !strings.Contains(string(body), "item-1") {
+		t.Fatalf("putUploadSessionChunks = %q, %v", body, err)
+	}
+}
+
+func TestPutUploadSessionChunksStopsWhenOffsetDoesNotAdvance(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 20)
+	puts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts++
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		_, _ = io.WriteString(w, `{"nextExpectedRanges":["0-"]}`)
+	}))
+	defer srv.Close()
+
+	if _, err := putUploadSessionChunks(context.Background(), srv.URL, bytes.NewReader(data), int64(len(data)), 0, 10); err == nil {
+		t.Fatalf("expected an error when the session offset does not advance")
+	}
+	if puts != 1 {
+		t.Fatalf("expected one rejected chunk, got %d", puts)
+	}
+}
+
+func TestDriveUploadSessionFailsWhenCommitReturns404(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+	localPath := filepath.Join(t.TempDir(), "report.bin")
+	if err := os.WriteFile(localPath, bytes.Repeat([]byte("x"), 20), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lookedUp := false
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1.0/me/drive/root:/report.bin:/createUploadSession":
+			_, _ = fmt.Fprintf(w, `{"uploadUrl":"%s/upload"}`, srv.URL)
+		case r.Method == http.MethodPut && r.URL.Path == "/upload":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, `{"nextExpectedRanges":[]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/upload":
+			// The session expired before the commit; an existing
+			// report.bin must not be reported as the upload.
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":{"code":"itemNotFound","message":"gone"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1.0/me/drive/root:/report.bin:":
+			lookedUp = true
+			_, _ = io.WriteString(w, `{"id":"old-item","name":"report.bin","size":20}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	code := runDriveUploadSession(rt, identityContext{Account: "me@contoso.com"}, localPath, info, "", "", "report.bin", "replace", 10, false)
+	if code == 0 || out.Len() != 0 || lookedUp {
+		t.Fatalf("expected an expired session error without a name lookup, got exit %d: %s", code, out.String())
+	}
+	if msg := rt.stderr.(*bytes.Buffer).String(); !strings.Contains(msg, "upload session expired") {
+		t.Fatalf("expected upload session expired, got %s", msg)
+	}
+}
@@ -7,6 +7,9 @@ type appError struct {
 	Message string
 	Hint    string
 	Exit    int
+
+	// Status is the HTTP status of the failed Graph request, if any.
+	Status int
 }
 
 func (e *appError) Error() string {
@@ -36,3 +39,9 @@ func transientError(msg, hint string) error {
 func notImplementedError(msg, hint string) error {
 	return &appError{Code: "not_implemented", Message: msg, Hint: hint, Exit: exitcode.NotImplemented}
 }
+
+// resyncRequiredError reports an expired or invalid delta token, which delta
+// queries see as HTTP 410.
+func resyncRequiredError(msg, hint string) error {
+	return &appError{Code: "resync_required", Message: msg, Hint: hint, Exit: exitcode.UsageError}
+}
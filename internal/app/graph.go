@@ -25,6 +25,12 @@ type identityContext struct {
 	Creds   config.Credentials
 	Store   *secrets.Store
 	Cfg     config.AppConfig
+	// Mailbox is a shared or delegated mailbox that mail commands act on
+	// instead of the signed-in user's own.
+	Mailbox string
+	// Scopes are needed in addition to those granted at login, such as
+	// Mail.ReadWrite.Shared for another user's mailbox.
+	Scopes []string
 }
 
 type graphErrorEnvelope struct {
@@ -104,7 +110,46 @@ func (rt *runtimeState) resolveIdentity() (identityContext, error) {
 	}, nil
 }
 
+// accessTokenMargin is how long before expiry a cached access token is
+// considered stale and refreshed.
+const accessTokenMargin = 5 * time.Minute
+
+// accessToken returns a cached access token while it is valid for at least
+// accessTokenMargin, and otherwise redeems the refresh token.
 func (rt *runtimeState) accessToken(id identityContext) (string, error) {
+	now := time.Now().UTC()
+	if rt.cachedToken.ValidFor(now, accessTokenMargin) && auth.HasScopes(rt.cachedToken.Scope, id.Scopes) {
+		return rt.cachedToken.AccessToken, nil
+	}
+	if cached, err := id.Store.GetAccessToken(id.Client, id.Account); err == nil && cached.ValidFor(now, accessTokenMargin) && auth.HasScopes(cached.Scope, id.Scopes) {
+		rt.cachedToken = cached
+		return cached.AccessToken, nil
+	}
+	return rt.refreshAccessToken(id)
+}
+
+// invalidateAccessToken drops the cached access token, e.g. after Graph
+// rejected it with 401.
+func (rt *runtimeState) invalidateAccessToken(id identityContext) {
+	rt.cachedToken = secrets.AccessToken{}
+	_ = id.Store.DeleteAccessToken(id.Client, id.Account)
+}
+
+func (rt *runtimeState) refreshAccessToken(id identityContext) (string, error) {
+	// Refresh tokens rotate on use; only one process may redeem at a time.
+	lock, err := secrets.LockToken(id.Client, id.Account)
+	if err != nil {
+		return "", transientError("could not lock token store", err.Error())
+	}
+	defer lock.Unlock()
+
+	// Another process may have refreshed while this one waited for the lock.
+	if cached, err := id.Store.GetAccessToken(id.Client, id.Account); err == nil &&
+		cached.AccessToken != rt.cachedToken.AccessToken && cached.ValidFor(time.Now().UTC(), accessTokenMargin) && auth.HasScopes(cached.Scope, id.Scopes) {
+		rt.cachedToken = cached
+		return cached.AccessToken, nil
+	}
+
 	tok, err := id.Store.GetToken(id.Client, id.Account)
 	if err != nil {
 		if errors.Is(err, secrets.ErrNotFound) {
@@ -119,7 +164,16 @@ func (rt *runtimeState) accessToken(id identityContext) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	refreshed, err := auth.RefreshAccessToken(ctx, id.Creds, tok.RefreshToken, auth.DefaultScopes, rt.lookup)
+	// Scopes beyond those granted at login are requested on demand; this
+	// works once the user or an admin has consented to them.
+	missing := auth.MissingScopes(tok.Scope, id.Scopes)
+	refreshed, err := auth.RefreshAccessToken(ctx, id.Creds, tok.RefreshToken, append(auth.RefreshScopes(tok.Scope), missing...), rt.lookup)
+	if err != nil && len(missing) > 0 {
+		return "", authRequiredError(
+			fmt.Sprintf("could not get %s for %s", strings.Join(missing, ", "), id.Account),
+			fmt.Sprintf("Consent with 'mo auth add %s --scope %s', then retry. Error: %v", id.Account, strings.Join(missing, ","), err),
+		)
+	}
 	if err != nil {
 		return "", authRequiredError(
 			"could not refresh access token",
@@ -128,9 +182,13 @@ func (rt *runtimeState) accessToken(id identityContext) (string, error) {
 	}
 
 	if strings.TrimSpace(refreshed.RefreshToken) != "" && refreshed.RefreshToken != tok.RefreshToken {
+		scope := refreshed.Scope
+		if strings.TrimSpace(scope) == "" {
+			scope = tok.Scope
+		}
 		if err := id.Store.PutToken(id.Client, id.Account, secrets.Token{
 			RefreshToken: refreshed.RefreshToken,
-			Scope:        refreshed.Scope,
+			Scope:        scope,
 		}); err != nil {
 			return "", authRequiredError(
 				"could not persist refreshed token",
@@ -138,31 +196,86 @@ func (rt *runtimeState) accessToken(id identityContext) (string, error) {
 			)
 		}
 	}
+
+	rt.cachedToken = accessTokenFromResponse(refreshed, time.Now().UTC())
+	// Caching is an optimization; a failed write only costs a refresh next time.
+	if refreshed.ExpiresIn > 0 {
+		_ = id.Store.PutAccessToken(id.Client, id.Account, rt.cachedToken)
+	}
 	return refreshed.AccessToken, nil
 }
 
-func (rt *runtimeState) graphRequest(id identityContext, method, path string, query url.Values, body any, out any) (string, error) {
-	rt.warnEndpointOverrides()
+func accessTokenFromResponse(tok auth.TokenResponse, now time.Time) secrets.AccessToken {
+	expiresIn := tok.ExpiresIn
+	if expiresIn <= 0 {
+		// Without expires_in the lifetime is unknown: assume a short one, and
+		// callers do not persist such tokens to the shared store.
+		expiresIn = int(accessTokenMargin.Seconds()) + 60
+	}
+	return secrets.AccessToken{
+		AccessToken: tok.AccessToken,
+		Scope:       tok.Scope,
+		ExpiresAt:   now.Add(time.Duration(expiresIn) * time.Second).Format(time.RFC3339),
+	}
+}
 
-	accessToken, err := rt.accessToken(id)
+func (rt *runtimeState) graphRequest(id identityContext, method, path string, query url.Values, body any, out any) (string, error) {
+	nextLink, err := rt.graphRequestURL(id, method, rt.graphURL(path, query), body, out)
 	if err != nil {
 		return "", err
 	}
+	return extractPageToken(nextLink), nil
+}
 
+func (rt *runtimeState) graphURL(path string, query url.Values) string {
 	base := strings.TrimRight(config.String(rt.lookup, "MO_GRAPH_BASE_URL", defaultGraphBaseURL), "/")
 	u := base + "/" + strings.TrimLeft(path, "/")
 	if len(query) > 0 {
 		u += "?" + query.Encode()
 	}
+	return u
+}
+
+// graphRequestURL executes a request against an absolute Graph URL and returns
+// the raw @odata.nextLink of the response, if any.
+func (rt *runtimeState) graphRequestURL(id identityContext, method, u string, body any, out any) (string, error) {
+	return rt.graphRequestHeader(id, method, u, nil, body, out)
+}
+
+// textBody is a request body sent as text/plain instead of JSON, such as a
+// base64 MIME message for sendMail.
+type textBody string
+
+// graphRequestHeader is graphRequestURL with extra request headers such as
+// Prefer or ConsistencyLevel.
+func (rt *runtimeState) graphRequestHeader(id identityContext, method, u string, header http.Header, body any, out any) (string, error) {
+	return rt.graphRequestContext(context.Background(), id, method, u, header, body, out)
+}
+
+// graphRequestContext is graphRequestHeader for long-running commands: the
+// request and the waits between retries end as soon as ctx is done.
+func (rt *runtimeState) graphRequestContext(ctx context.Context, id identityContext, method, u string, header http.Header, body any, out any) (string, error) {
+	rt.warnEndpointOverrides()
+
+	accessToken, err := rt.accessToken(id)
+	if err != nil {
+		return "", err
+	}
 
 	var reqBody io.Reader
 	var payload []byte
-	if body != nil {
+	contentType := "application/json"
+	if text, ok := body.(textBody); ok {
+		payload = []byte(text)
+		contentType = "text/plain"
+	} else if body != nil {
 		data, err := json.Marshal(body)
 		if err != nil {
 			return "", fmt.Errorf("marshal request body: %w", err)
 		}
 		payload = data
+	}
+	if payload != nil {
 		reqBody = bytes.NewReader(payload)
 	}
 
@@ -173,27 +286,33 @@ func (rt *runtimeState) graphRequest(id identityContext, method, path string, qu
 	var graphMessage string
 
 	const maxAttempts = 3
+	reauthorized := false
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		if payload != nil {
 			reqBody = bytes.NewReader(payload)
 		}
 
-		req, err := http.NewRequest(method, u, reqBody)
+		req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
 		if err != nil {
 			return "", fmt.Errorf("create request: %w", err)
 		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
 		req.Header.Set("Authorization", "Bearer "+accessToken)
 		req.Header.Set("Accept", "application/json")
 		if body != nil {
-			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Type", contentType)
 		}
 
 		resp, err := httpClient.Do(req)
 		if err != nil {
-			if attempt == maxAttempts {
+			if attempt == maxAttempts || ctx.Err() != nil {
+				return "", transientError("graph request failed", err.Error())
+			}
+			if err := sleepContext(ctx, backoffDuration(attempt)); err != nil {
 				return "", transientError("graph request failed", err.Error())
 			}
-			time.Sleep(backoffDuration(attempt))
 			continue
 		}
 
@@ -213,31 +332,44 @@ func (rt *runtimeState) graphRequest(id identityContext, method, path string, qu
 			break
 		}
 
+		if statusCode == http.StatusUnauthorized && !reauthorized {
+			// The cached token may have been revoked early; refresh once.
+			reauthorized = true
+			rt.invalidateAccessToken(id)
+			if accessToken, err = rt.refreshAccessToken(id); err != nil {
+				return "", err
+			}
+			attempt--
+			continue
+		}
+
 		if shouldRetryStatus(statusCode) && attempt < maxAttempts {
-			time.Sleep(retryDelay(resp, attempt))
+			if err := sleepContext(ctx, retryDelay(resp, attempt)); err != nil {
+				return "", transientError("graph request failed", err.Error())
+			}
 			continue
 		}
 		return "", mapGraphError(statusCode, graphCode, graphMessage)
 	}
 
-	nextPage := ""
+	nextLink := ""
 	var meta struct {
 		NextLink string `json:"@odata.nextLink"`
 	}
 	if err := json.Unmarshal(respBody, &meta); err == nil {
-		nextPage = extractPageToken(meta.NextLink)
+		nextLink = strings.TrimSpace(meta.NextLink)
 	}
 
 	if out != nil {
 		if len(respBody) == 0 {
-			return nextPage, nil
+			return nextLink, nil
 		}
 		if err := json.Unmarshal(respBody, out); err != nil {
 			return "", fmt.Errorf("parse response: %w", err)
 		}
 	}
 
-	return nextPage, nil
+	return nextLink, nil
 }
 
 func shouldRetryStatus(status int) bool {
@@ -259,6 +391,18 @@ func retryDelay(resp *http.Response, attempt int) time.Duration {
 	return backoffDuration(attempt)
 }
 
+// sleepContext waits for d, or returns ctx's error once it is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 func backoffDuration(attempt int) time.Duration {
 	if attempt < 1 {
 		attempt = 1
@@ -328,22 +472,25 @@ func (rt *runtimeState) warnURLOverride(envKey, defaultValue string) {
 }
 
 func mapGraphError(status int, graphCode, graphMessage string) error {
+	var err error
 	switch {
 	case status == http.StatusUnauthorized:
-		return authRequiredError("graph request unauthorized", graphMessage)
+		err = authRequiredError("graph request unauthorized", graphMessage)
 	case status == http.StatusForbidden:
-		return permissionError("graph request forbidden", graphMessage)
+		err = permissionError("graph request forbidden", graphMessage)
 	case status == http.StatusNotFound:
-		return notFoundError("graph resource not found", graphMessage)
+		err = notFoundError("graph resource not found", graphMessage)
 	case status == http.StatusTooManyRequests || status >= 500:
-		return transientError("graph transient failure", graphMessage)
+		err = transientError("graph transient failure", graphMessage)
 	default:
 		msg := strings.TrimSpace(graphMessage)
 		if msg == "" {
 			msg = fmt.Sprintf("graph request failed with status %d", status)
 		}
-		return usageError(msg, graphCode)
+		err = usageError(msg, graphCode)
 	}
+	err.(*appError).Status = status
+	return err
 }
 
 func extractPageToken(nextLink string) string {
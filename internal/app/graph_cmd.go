@@ -0,0 +1,269 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const graphUsage = "Usage: mo graph <GET|POST|PATCH|PUT|DELETE> <path> [--query QUERY]... [--header 'Name: value']... [--body JSON|@file|@-] [--all [--limit N]]"
+
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func runGraph(rt *runtimeState, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("graph"))
+		return exitcode.Success
+	}
+
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var queries, headers stringList
+	fs.Var(&queries, "query", "Query string, e.g. '$top=5&$select=id' (repeatable)")
+	fs.Var(&headers, "header", "Extra request header 'Name: value' (repeatable)")
+	bodyArg := fs.String("body", "", "JSON body, @file, or @- for stdin")
+	all := fs.Bool("all", false, "Follow @odata.nextLink and collect value arrays (GET only)")
+	limit := fs.Int("limit", 0, "Stop after N items (requires --all)")
+	if err := fs.Parse(normalizeTwoPositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid graph flags", graphUsage))
+	}
+	if fs.NArg() != 2 {
+		return rt.failErr(usageError("method and path are required", graphUsage))
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(fs.Arg(0)))
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete:
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unsupported method %q", fs.Arg(0)), graphUsage))
+	}
+	path, query, err := rt.parseGraphPath(fs.Arg(1))
+	if err != nil {
+		return rt.failErr(err)
+	}
+	for _, raw := range queries {
+		q, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(raw), "?"))
+		if err != nil {
+			return rt.failErr(usageError(fmt.Sprintf("invalid --query %q", raw), "Use key=value pairs joined with &, e.g. '$top=5&$select=id'."))
+		}
+		for k, vs := range q {
+			for _, v := range vs {
+				query.Add(k, v)
+			}
+		}
+	}
+	header, err := parseGraphHeaders(headers)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	var body json.RawMessage
+	if strings.TrimSpace(*bodyArg) != "" {
+		raw, err := readGraphBody(*bodyArg)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		body = raw
+	}
+	if *limit < 0 {
+		return rt.failErr(usageError("--limit must not be negative", graphUsage))
+	}
+	if *limit > 0 && !*all {
+		return rt.failErr(usageError("--limit requires --all", graphUsage))
+	}
+	if *all && method != http.MethodGet {
+		return rt.failErr(usageError("--all is only supported for GET", graphUsage))
+	}
+
+	id, err := rt.resolveIdentity()
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	if *all {
+		return rt.writeList(id, path, query, listOptions{All: *all, Limit: *limit, Header: header}, nil, nil)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	resp, err := rt.graphCommandRequest(ctx, id, method, path, query, header, body)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	defer resp.Body.Close()
+	if !isJSONContentType(resp.Header.Get("Content-Type")) && resp.ContentLength != 0 {
+		// Content such as /$value or photos is passed through untouched.
+		if _, err := io.Copy(rt.stdout, resp.Body); err != nil {
+			return rt.fail("write_failed", "failed to write output", err.Error(), exitcode.UsageError)
+		}
+		return exitcode.Success
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return rt.failErr(transientError("graph response read failed", err.Error()))
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return rt.writeJSON(map[string]any{"ok": true, "method": method, "path": path})
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return rt.failErr(transientError("graph response is not valid JSON", err.Error()))
+	}
+	return rt.writeJSON(out)
+}
+
+// graphCommandRequest sends a mo graph request and returns the successful
+// response with its body unread, so non-JSON content can be streamed.
+// Expired tokens, network failures, throttling and server errors are retried
+// like graphRequest does, until ctx is done.
+func (rt *runtimeState) graphCommandRequest(ctx context.Context, id identityContext, method, path string, query url.Values, header http.Header, body json.RawMessage) (*http.Response, error) {
+	const maxAttempts = 3
+	reauthorized := false
+	for attempt := 1; ; attempt++ {
+		var reqBody io.Reader
+		contentType := ""
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+			contentType = "application/json"
+		}
+		resp, err := rt.driveRawRequestContext(ctx, id, method, path, query, header, reqBody, contentType, int64(len(body)))
+		if err != nil {
+			var ae *appError
+			if attempt == maxAttempts || ctx.Err() != nil || !errors.As(err, &ae) || ae.Exit != exitcode.TransientError {
+				return nil, err
+			}
+			if err := sleepContext(ctx, backoffDuration(attempt)); err != nil {
+				return nil, transientError("graph request failed", err.Error())
+			}
+			continue
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized && body != nil && !reauthorized {
+			// driveRawRequest only refreshes for bodiless requests.
+			reauthorized = true
+			rt.invalidateAccessToken(id)
+			if _, err := rt.refreshAccessToken(id); err != nil {
+				return nil, err
+			}
+			attempt--
+			continue
+		}
+		if shouldRetryStatus(resp.StatusCode) && attempt < maxAttempts {
+			if err := sleepContext(ctx, retryDelay(resp, attempt)); err != nil {
+				return nil, transientError("graph request failed", err.Error())
+			}
+			continue
+		}
+		return nil, graphErrorFromBody(resp.StatusCode, data)
+	}
+}
+
+func isJSONContentType(v string) bool {
+	mediaType, _, err := mime.ParseMediaType(v)
+	if err != nil {
+		return strings.TrimSpace(v) == ""
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// parseGraphPath splits an optional inline query off path and defaults the
+// API version to v1.0. Absolute URLs are accepted when they point at the
+// configured Graph host, but only their path and query are kept. The path
+// stays percent-encoded so escaped segments such as %2F or %23 reach Graph
+// unchanged.
+func (rt *runtimeState) parseGraphPath(raw string) (string, url.Values, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(escapeGraphPath(raw))
+	if err != nil || raw == "" {
+		return "", nil, usageError(fmt.Sprintf("invalid path %q", raw), "Use a Graph path such as /v1.0/me/mailFolders.")
+	}
+	if u.IsAbs() && !rt.sameGraphOrigin(raw) {
+		return "", nil, usageError(fmt.Sprintf("refusing request to %s", u.Host), "Pass a Graph path such as /v1.0/me; mo graph only talks to the configured Graph endpoint.")
+	}
+	path := "/" + strings.TrimLeft(u.EscapedPath(), "/")
+	if !strings.HasPrefix(path, "/v1.0/") && !strings.HasPrefix(path, "/beta/") {
+		path = "/v1.0" + path
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return "", nil, usageError(fmt.Sprintf("invalid query in path %q", raw), "Use --query for query parameters.")
+	}
+	return path, query, nil
+}
+
+// escapeGraphPath percent-encodes bytes such as spaces that are not valid in
+// a URL while leaving existing escapes and delimiters alone, so url.Parse
+// keeps the caller's encoding in RawPath.
+func escapeGraphPath(raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~!$&'()*+,;=:@/?#%[]", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func parseGraphHeaders(raw []string) (http.Header, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	h := http.Header{}
+	for _, line := range raw {
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, usageError(fmt.Sprintf("invalid --header %q", line), "Use 'Name: value', e.g. --header 'ConsistencyLevel: eventual'.")
+		}
+		if strings.EqualFold(name, "Authorization") {
+			return nil, usageError("--header cannot set Authorization", "mo graph always authenticates as the selected account.")
+		}
+		h.Add(name, strings.TrimSpace(value))
+	}
+	return h, nil
+}
+
+// maxGraphBodyBytes bounds --body; Graph rejects larger JSON payloads anyway.
+const maxGraphBodyBytes = 4 << 20
+
+func readGraphBody(arg string) (json.RawMessage, error) {
+	data := []byte(arg)
+	if strings.HasPrefix(arg, "@") {
+		var err error
+		if data, err = readInput("--body", strings.TrimPrefix(arg, "@"), maxGraphBodyBytes); err != nil {
+			return nil, err
+		}
+	} else if len(data) > maxGraphBodyBytes {
+		return nil, usageError("--body is larger than 4 MiB", "Pass a smaller input.")
+	}
+	if !json.Valid(data) {
+		return nil, usageError("--body is not valid JSON", "Pass a JSON document inline, as @file.json, or via @- on stdin.")
+	}
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,180 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGraphPathDefaultsVersionAndSplitsQuery(t *testing.T) {
+	rt := &runtimeState{lookup: fakeEnv(map[string]string{})}
+	path, q, err := rt.parseGraphPath("me/mailFolders?$top=5")
+	if err != nil {
+		t.Fatalf("parseGraphPath returned error: %v", err)
+	}
+	if path != "/v1.0/me/mailFolders" || q.Get("$top") != "5" {
+		t.Fatalf("unexpected path %q query %v", path, q)
+	}
+
+	path, _, err = rt.parseGraphPath("https://graph.microsoft.com/beta/me")
+	if err != nil || path != "/beta/me" {
+		t.Fatalf("expected /beta/me from absolute URL, got %q (%v)", path, err)
+	}
+	if _, _, err := rt.parseGraphPath("https://evil.example.com/v1.0/me"); err == nil {
+		t.Fatalf("expected foreign host to be rejected")
+	}
+}
+
+func TestParseGraphPathKeepsEscapedSegments(t *testing.T) {
+	var gotURI string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURI = r.RequestURI
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	path, _, err := rt.parseGraphPath("/me/drive/root:/a%2Fb C%23%3F.txt:")
+	if err != nil {
+		t.Fatalf("parseGraphPath returned error: %v", err)
+	}
+	want := "/v1.0/me/drive/root:/a%2Fb%20C%23%3F.txt:"
+	if path != want {
+		t.Fatalf("expected escaped path %q, got %q", want, path)
+	}
+	resp, err := rt.graphCommandRequest(context.Background(), identityContext{}, http.MethodGet, path, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("graphCommandRequest returned error: %v", err)
+	}
+	resp.Body.Close()
+	if gotURI != want {
+		t.Fatalf("expected request URI %q, got %q", want, gotURI)
+	}
+}
+
+func TestParseGraphHeadersRejectsAuthorization(t *testing.T) {
+	h, err := parseGraphHeaders([]string{"ConsistencyLevel: eventual"})
+	if err != nil || h.Get("ConsistencyLevel") != "eventual" {
+		t.Fatalf("unexpected header parse: %v %v", h, err)
+	}
+	if _, err := parseGraphHeaders([]string{"Authorization: Bearer x"}); err == nil {
+		t.Fatalf("expected Authorization override to be rejected")
+	}
+	if _, err := parseGraphHeaders([]string{"no-colon"}); err == nil {
+		t.Fatalf("expected malformed header to be rejected")
+	}
+}
+
+func TestReadGraphBodyFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(path, []byte(`{"displayName":"x"}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	raw, err := readGraphBody("@" + path)
+	if err != nil || string(raw) != `{"displayName":"x"}` {
+		t.Fatalf("unexpected body %q (%v)", raw, err)
+	}
+	if _, err := readGraphBody("{not json"); err == nil {
+		t.Fatalf("expected invalid JSON to be rejected")
+	}
+}
+
+func TestGraphRequestHeaderSendsBodyAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1.0/me/todo/lists" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Prefer") != "return=minimal" {
+			t.Errorf("expected Prefer header, got %q", r.Header.Get("Prefer"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"displayName":"x"}` {
+			t.Errorf("unexpected body %s", body)
+		}
+		_, _ = w.Write([]byte(`{"id":"list-1"}`))
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	var out any
+	_, err := rt.graphRequestHeader(identityContext{}, http.MethodPost, rt.graphURL("/v1.0/me/todo/lists", nil),
+		http.Header{"Prefer": {"return=minimal"}}, json.RawMessage(`{"displayName":"x"}`), &out)
+	if err != nil {
+		t.Fatalf("graphRequestHeader returned error: %v", err)
+	}
+	if m, _ := out.(map[string]any); m["id"] != "list-1" {
+		t.Fatalf("unexpected response %v", out)
+	}
+}
+
+func TestGraphCommandRequestKeepsRawContent(t *testing.T) {
+	photo := []byte{0xff, 0xd8, 0xff, 0x00}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(photo)
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	resp, err := rt.graphCommandRequest(context.Background(), identityContext{}, http.MethodGet, "/v1.0/me/photo/$value", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("graphCommandRequest returned error: %v", err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+	if isJSONContentType(resp.Header.Get("Content-Type")) || !bytes.Equal(got, photo) {
+		t.Fatalf("expected raw photo bytes, got %q (%s)", got, resp.Header.Get("Content-Type"))
+	}
+	for _, ct := range []string{"application/json; odata.metadata=minimal", "application/problem+json", ""} {
+		if !isJSONContentType(ct) {
+			t.Fatalf("expected %q to be treated as JSON", ct)
+		}
+	}
+}
+
+func TestGraphCommandRequestRetriesNetworkErrors(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			// Drop the connection without a response.
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"me"}`))
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	resp, err := rt.graphCommandRequest(context.Background(), identityContext{}, http.MethodGet, "/v1.0/me", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("graphCommandRequest returned error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if requests != 2 {
+		t.Fatalf("expected a retry after the dropped connection, got %d requests", requests)
+	}
+}
+
+func TestReadGraphBodyRejectsOversizedBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.json")
+	big := append([]byte(`"`), append(bytes.Repeat([]byte("x"), maxGraphBodyBytes), '"')...)
+	if err := os.WriteFile(path, big, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, err := readGraphBody("@" + path)
+	if ae, ok := err.(*appError); !ok || ae.Message != "--body is larger than 4 MiB" {
+		t.Fatalf("expected size error, got %v", err)
+	}
+}
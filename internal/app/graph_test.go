@@ -62,3 +62,11 @@ func TestRetryDelayUsesRetryAfterWithinCap(t *testing.T) {
 		t.Fatalf("retryDelay = %s, want 5s", got)
 	}
 }
+
+func TestMapGraphErrorKeepsGoneGeneric(t *testing.T) {
+	err := mapGraphError(http.StatusGone, "itemGone", "upload session is gone")
+	ae, ok := err.(*appError)
+	if !ok || ae.Code != "usage_error" || ae.Message != "upload session is gone" || ae.Status != http.StatusGone {
+		t.Fatalf("unexpected 410 mapping: %#v", err)
+	}
+}
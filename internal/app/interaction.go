@@ -25,3 +25,29 @@ func confirmAction(rt *runtimeState, prompt string) (bool, error) {
 	answer := strings.ToLower(strings.TrimSpace(line))
 	return answer == "y" || answer == "yes", nil
 }
+
+// readInput reads the file at path, or stdin for "-", for flags such as
+// --body-file and --mime that keep large input out of argv. A positive limit
+// rejects larger input instead of cutting it short; name labels errors.
+func readInput(name, path string, limit int64) ([]byte, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, usageError("could not read "+name, err.Error())
+		}
+		defer f.Close()
+		r = f
+	}
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, usageError("could not read "+name, err.Error())
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return nil, usageError(fmt.Sprintf("%s is larger than %d MiB", name, limit>>20), "Pass a smaller input.")
+	}
+	return data, nil
+}
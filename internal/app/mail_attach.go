@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const (
+	// mailInlineAttachmentMax is the largest attachment Graph accepts inline as
+	// base64 in a single request; bigger files need an upload session.
+	mailInlineAttachmentMax = 3 << 20
+	// mailUploadChunkBytes stays under the 4MB per-request cap of Outlook
+	// attachment upload sessions.
+	mailUploadChunkBytes = 3 << 20
+)
+
+type mailAttachment struct {
+	Path        string
+	Name        string
+	ContentType string
+	Size        int64
+}
+
+func loadMailAttachments(paths []string) ([]mailAttachment, error) {
+	out := make([]mailAttachment, 0, len(paths))
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		st, err := os.Stat(p)
+		if err != nil {
+			return nil, usageError(fmt.Sprintf("cannot read attachment %s", p), err.Error())
+		}
+		if !st.Mode().IsRegular() {
+			return nil, usageError(fmt.Sprintf("attachment %s is not a regular file", p), "Pass file paths to --attach.")
+		}
+		ct := mime.TypeByExtension(strings.ToLower(filepath.Ext(p)))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		out = append(out, mailAttachment{Path: p, Name: filepath.Base(p), ContentType: ct, Size: st.Size()})
+	}
+	return out, nil
+}
+
+// attachmentsFitInline reports whether all attachments can travel base64
+// encoded inside one sendMail/reply request.
+func attachmentsFitInline(atts []mailAttachment) bool {
+	total := int64(0)
+	for _, a := range atts {
+		total += a.Size
+	}
+	return total <= mailInlineAttachmentMax
+}
+
+func inlineAttachment(a mailAttachment) (map[string]any, error) {
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		return nil, usageError(fmt.Sprintf("cannot read attachment %s", a.Path), err.Error())
+	}
+	return map[string]any{
+		"@odata.type":  "#microsoft.graph.fileAttachment",
+		"name":         a.Name,
+		"contentType":  a.ContentType,
+		"contentBytes": base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+func inlineAttachments(atts []mailAttachment) ([]map[string]any, error) {
+	out := make([]map[string]any, 0, len(atts))
+	for _, a := range atts {
+		v, err := inlineAttachment(a)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// addMessageAttachments attaches files to an existing draft at msgPath,
+// switching to an attachment upload session for files over 3MB.
+func (rt *runtimeState) addMessageAttachments(id identityContext, msgPath string, atts []mailAttachment) error {
+	for _, a := range atts {
+		if a.Size <= mailInlineAttachmentMax {
+			payload, err := inlineAttachment(a)
+			if err != nil {
+				return err
+			}
+			if _, err := rt.graphRequest(id, http.MethodPost, msgPath+"/attachments", nil, payload, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var session uploadSessionStatus
+		_, err := rt.graphRequest(id, http.MethodPost, msgPath+"/attachments/createUploadSession", nil, map[string]any{
+			"AttachmentItem": map[string]any{
+				"attachmentType": "file",
+				"name":           a.Name,
+				"size":           a.Size,
+				"contentType":    a.ContentType,
+			},
+		}, &session)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(session.UploadURL) == "" {
+			return transientError("attachment upload session missing uploadUrl", "Retry the command.")
+		}
+		f, err := os.Open(a.Path)
+		if err != nil {
+			return usageError(fmt.Sprintf("cannot read attachment %s", a.Path), err.Error())
+		}
+		_, err = putUploadSessionChunks(context.Background(), session.UploadURL, f, a.Size, 0, mailUploadChunkBytes)
+		_ = f.Close()
+		if err != nil {
+			cancelUploadSession(session.UploadURL)
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDraftWithAttachments creates a draft from message, attaches files and
+// sends it. Used when attachments are too large for a single request. The
+// draft is deleted again if anything fails before sending.
+func (rt *runtimeState) sendDraftWithAttachments(id identityContext, message map[string]any, atts []mailAttachment) error {
+	var draft struct {
+		ID string `json:"id"`
+	}
+	if _, err := rt.graphRequest(id, http.MethodPost, mailRoot(id)+"/messages", nil, message, &draft); err != nil {
+		return err
+	}
+	return rt.attachAndSendDraft(id, draft.ID, atts)
+}
+
+func (rt *runtimeState) attachAndSendDraft(id identityContext, draftID string, atts []mailAttachment) error {
+	if strings.TrimSpace(draftID) == "" {
+		return transientError("draft creation returned no id", "Retry the command.")
+	}
+	draftPath := messagePath(id, draftID)
+	if err := rt.addMessageAttachments(id, draftPath, atts); err != nil {
+		_, _ = rt.graphRequest(id, http.MethodDelete, draftPath, nil, nil, nil)
+		return err
+	}
+	if _, err := rt.graphRequest(id, http.MethodPost, draftPath+"/send", nil, nil, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func runMailAttachments(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail attachments <message-id>"
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return rt.failErr(usageError("message id is required", usage))
+	}
+	msgID := strings.TrimSpace(args[0])
+
+	q := url.Values{}
+	q.Set("$select", "id,name,contentType,size,isInline,lastModifiedDateTime")
+	path := messagePath(id, msgID) + "/attachments"
+	return rt.writeList(id, path, q, listOptions{All: true}, func(it map[string]any) string {
+		return fmt.Sprintf("%s\t%d\t%s", asString(it["id"]), asInt64(it["size"]), strings.ReplaceAll(asString(it["name"]), "\t", " "))
+	}, map[string]any{"message_id": msgID})
+}
+
+func runMailAttachment(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
+		return exitcode.Success
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	switch sub {
+	case "download":
+		return runMailAttachmentDownload(rt, id, args[1:])
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown mail attachment subcommand %q", sub), "Usage: mo mail attachment download <message-id> <attachment-id> [--out PATH]"))
+	}
+}
+
+func runMailAttachmentDownload(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail attachment download <message-id> <attachment-id> [--out PATH]"
+	fs := flag.NewFlagSet("mail attachment download", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	outPath := fs.String("out", "", "Output file path or directory")
+	if err := fs.Parse(normalizeTwoPositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid mail attachment download flags", usage))
+	}
+	if fs.NArg() != 2 {
+		return rt.failErr(usageError("message id and attachment id are required", usage))
+	}
+	msgID := strings.TrimSpace(fs.Arg(0))
+	attID := strings.TrimSpace(fs.Arg(1))
+	if msgID == "" || attID == "" {
+		return rt.failErr(usageError("message id and attachment id are required", usage))
+	}
+
+	attPath := messagePath(id, msgID) + "/attachments/" + url.PathEscape(attID)
+	q := url.Values{}
+	q.Set("$select", "id,name,contentType,size")
+	var meta map[string]any
+	if _, err := rt.graphRequest(id, http.MethodGet, attPath, q, nil, &meta); err != nil {
+		return rt.failErr(err)
+	}
+	dest, err := resolveDriveDownloadPath(strings.TrimSpace(*outPath), asString(meta["name"]), attID)
+	if err != nil {
+		return rt.failErr(usageError("invalid --out path", err.Error()))
+	}
+	if mkErr := os.MkdirAll(filepath.Dir(dest), 0o755); mkErr != nil {
+		return rt.failErr(transientError("failed to create output directory", mkErr.Error()))
+	}
+
+	tmp := dest + ".part"
+	n, _, err := rt.downloadDriveContent(id, attPath+"/$value", tmp, 0, "")
+	if err != nil {
+		_ = os.Remove(tmp)
+		return rt.failErr(err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return rt.failErr(transientError("failed to place output file", err.Error()))
+	}
+	return rt.writeJSON(map[string]any{
+		"downloaded":    true,
+		"message_id":    msgID,
+		"attachment_id": attID,
+		"name":          asString(meta["name"]),
+		"content_type":  asString(meta["contentType"]),
+		"path":          dest,
+		"bytes":         n,
+	})
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoadMailAttachmentsDetectsTypeAndSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	atts, err := loadMailAttachments([]string{path})
+	if err != nil {
+		t.Fatalf("loadMailAttachments returned error: %v", err)
+	}
+	if len(atts) != 1 || atts[0].Name != "report.pdf" || atts[0].ContentType != "application/pdf" || atts[0].Size != 8 {
+		t.Fatalf("unexpected attachment %+v", atts)
+	}
+	if !attachmentsFitInline(atts) {
+		t.Fatalf("expected small attachment to fit inline")
+	}
+	if attachmentsFitInline([]mailAttachment{{Size: 2 << 20}, {Size: 2 << 20}}) {
+		t.Fatalf("expected 4MB total to need an upload session")
+	}
+	if _, err := loadMailAttachments([]string{dir}); err == nil {
+		t.Fatalf("expected directory to be rejected")
+	}
+}
+
+func TestAddMessageAttachmentsUsesUploadSessionForLargeFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.bin")
+	size := int64(mailInlineAttachmentMax + 1024)
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), int(size)), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var mu sync.Mutex
+	var received int64
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/attachments/createUploadSession"):
+			var body map[string]map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["AttachmentItem"]["name"] != "big.bin" {
+				t.Errorf("unexpected AttachmentItem %v", body)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"uploadUrl": srv.URL + "/upload/1"})
+		case r.Method == http.MethodPut && r.URL.Path == "/upload/1":
+			if r.Header.Get("Authorization") != "" {
+				t.Errorf("upload URL must not receive the bearer token")
+			}
+			n, _ := io.Copy(io.Discard, r.Body)
+			mu.Lock()
+			received += n
+			done := received >= size
+			mu.Unlock()
+			if done {
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"nextExpectedRanges": []string{"3145728-"}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	atts, err := loadMailAttachments([]string{path})
+	if err != nil {
+		t.Fatalf("loadMailAttachments returned error: %v", err)
+	}
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	if err := rt.addMessageAttachments(identityContext{}, "/v1.0/me/messages/draft-1", atts); err != nil {
+		t.Fatalf("addMessageAttachments returned error: %v", err)
+	}
+	if received != size {
+		t.Fatalf("uploaded %d bytes, want %d", received, size)
+	}
+}
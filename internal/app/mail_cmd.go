@@ -1,17 +1,23 @@
 package app
 
 import (
+	"bytes"
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"io"
+	"net/mail"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/svaruag/mocli/internal/exitcode"
 )
 
 func runMail(rt *runtimeState, args []string) int {
+	mailbox, args, err := splitMailboxFlag(args)
+	if err != nil {
+		return rt.failErr(err)
+	}
 	if len(args) == 0 || isHelpToken(args[0]) {
 		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
 		return exitcode.Success
@@ -21,101 +27,225 @@ func runMail(rt *runtimeState, args []string) int {
 	rest := args[1:]
 	switch sub {
 	case "list":
-		id, err := rt.resolveIdentity()
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
 		if err != nil {
 			return rt.failErr(err)
 		}
 		return runMailList(rt, id, rest)
+	case "search":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailSearch(rt, id, rest)
+	case "sync":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailSync(rt, id, rest)
+	case "watch":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailWatch(rt, id, rest)
+	case "thread":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailThread(rt, id, rest)
+	case "export":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailExport(rt, id, rest)
 	case "get":
-		id, err := rt.resolveIdentity()
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
 		if err != nil {
 			return rt.failErr(err)
 		}
 		return runMailGet(rt, id, rest)
 	case "send":
-		id, err := rt.resolveIdentity()
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
 		if err != nil {
 			return rt.failErr(err)
 		}
 		return runMailSend(rt, id, rest)
+	case "reply", "reply-all", "forward":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailRespond(rt, id, mailRespondActions[sub], rest)
+	case "move", "copy":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailMoveOrCopy(rt, id, sub, rest)
+	case "delete":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailDelete(rt, id, rest)
+	case "mark":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailMark(rt, id, rest)
+	case "flag":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailFlag(rt, id, rest)
+	case "categorize":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailCategorize(rt, id, rest)
+	case "categories":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailCategories(rt, id, rest)
+	case "folders":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailFolders(rt, id, rest)
+	case "folder":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailFolder(rt, id, rest)
+	case "settings":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailSettings(rt, id, rest)
+	case "autoreply":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailAutoreply(rt, id, rest)
+	case "rules":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailRules(rt, id, rest)
+	case "draft":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailDraft(rt, id, rest)
+	case "attachments":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailAttachments(rt, id, rest)
+	case "attachment":
+		id, err := rt.resolveMailIdentity(mailbox, sub, rest)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		return runMailAttachment(rt, id, rest)
 	default:
 		return rt.failErr(usageError(fmt.Sprintf("unknown mail subcommand %q", sub), "Run 'mo mail help' for usage."))
 	}
 }
 
+const mailListUsage = "Usage: mo mail list [--max N] [--page TOKEN] [--all [--limit N]] [--from RFC3339] [--to RFC3339] [--folder FOLDER] [--search KQL] [--from-address EMAIL] [--unread] [--has-attachments] [--importance low|normal|high] [--category NAME]"
+
 func runMailList(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("mail list", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	max := fs.Int("max", 20, "Max messages")
-	page := fs.String("page", "", "Page token")
-	from := fs.String("from", "", "Filter from received time (RFC3339)")
-	to := fs.String("to", "", "Filter to received time (RFC3339)")
-	folder := fs.String("folder", "", "Mail folder id/name")
+	list := addListFlags(fs, 20)
+	filters := addMailFilterFlags(fs)
+	folder := fs.String("folder", "", "Mail folder id, well-known name, or path such as Inbox/Projects")
+	search := fs.String("search", "", "KQL query, e.g. 'from:alice subject:report'")
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid mail list flags", "Usage: mo mail list [--max N] [--page TOKEN] [--from RFC3339] [--to RFC3339]"))
+		return rt.failErr(usageError("invalid mail list flags", mailListUsage))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("mail list does not take positional arguments", "Run 'mo mail list --help'."))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
-	}
-	if strings.TrimSpace(*from) != "" {
-		if _, err := time.Parse(time.RFC3339, *from); err != nil {
-			return rt.failErr(usageError("invalid --from timestamp", "Use RFC3339 format, e.g. 2026-02-18T00:00:00Z."))
-		}
-	}
-	if strings.TrimSpace(*to) != "" {
-		if _, err := time.Parse(time.RFC3339, *to); err != nil {
-			return rt.failErr(usageError("invalid --to timestamp", "Use RFC3339 format, e.g. 2026-02-19T00:00:00Z."))
-		}
-	}
-
-	path := "/v1.0/me/messages"
-	if strings.TrimSpace(*folder) != "" {
-		path = "/v1.0/me/mailFolders/" + url.PathEscape(strings.TrimSpace(*folder)) + "/messages"
-	}
+	return rt.listMessages(id, fs, list, filters, *folder, *search)
+}
 
-	q := url.Values{}
-	q.Set("$top", fmt.Sprintf("%d", *max))
-	q.Set("$orderby", "receivedDateTime desc")
-	q.Set("$select", "id,subject,from,receivedDateTime,isRead,bodyPreview")
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
-	filters := make([]string, 0, 2)
-	if strings.TrimSpace(*from) != "" {
-		filters = append(filters, "receivedDateTime ge "+strings.TrimSpace(*from))
-	}
-	if strings.TrimSpace(*to) != "" {
-		filters = append(filters, "receivedDateTime le "+strings.TrimSpace(*to))
+func runMailSearch(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail search <KQL> [--folder FOLDER] [--max N] [--page TOKEN] [--all [--limit N]] [--from RFC3339] [--to RFC3339] [--from-address EMAIL] [--unread] [--has-attachments] [--importance low|normal|high] [--category NAME]"
+	fs := flag.NewFlagSet("mail search", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	list := addListFlags(fs, 20)
+	filters := addMailFilterFlags(fs)
+	folder := fs.String("folder", "", "Mail folder id, well-known name, or path such as Inbox/Projects")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid mail search flags", usage))
 	}
-	if len(filters) > 0 {
-		q.Set("$filter", strings.Join(filters, " and "))
+	if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+		return rt.failErr(usageError("search query is required", usage))
 	}
+	return rt.listMessages(id, fs, list, filters, *folder, fs.Arg(0))
+}
 
-	var resp struct {
-		Value []map[string]any `json:"value"`
+// listMessages runs mail list and mail search. With a KQL query the filters
+// are folded into $search and no $orderby is sent, since Graph allows neither
+// next to $search; results then come back newest first by default.
+func (rt *runtimeState) listMessages(id identityContext, fs *flag.FlagSet, list *listOptions, filters *mailFilterFlags, folder, kql string) int {
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
-	next, err := rt.graphRequest(id, "GET", path, q, nil, &resp)
-	if err != nil {
+	if err := filters.parsed(fs); err != nil {
 		return rt.failErr(err)
 	}
 
-	if rt.globals.Plain {
-		for _, it := range resp.Value {
-			idv, _ := it["id"].(string)
-			subj, _ := it["subject"].(string)
-			recv, _ := it["receivedDateTime"].(string)
-			_, _ = fmt.Fprintf(rt.stdout, "%s\t%s\t%s\n", idv, recv, strings.ReplaceAll(subj, "\t", " "))
+	path := mailRoot(id) + "/messages"
+	if strings.TrimSpace(folder) != "" {
+		folderID, err := rt.resolveMailFolder(id, folder)
+		if err != nil {
+			return rt.failErr(err)
 		}
-		if strings.TrimSpace(next) != "" {
-			_, _ = fmt.Fprintf(rt.stdout, "next_page\t%s\n", next)
+		path = mailFolderPath(id, folderID) + "/messages"
+	}
+
+	q := url.Values{}
+	list.apply(q)
+	q.Set("$select", mailListSelect)
+	if strings.TrimSpace(kql) != "" {
+		q.Set("$search", filters.search(kql))
+		// Search results page with $skip rather than $skiptoken.
+		if page := strings.TrimSpace(list.Page); isDigits(page) {
+			q.Del("$skiptoken")
+			q.Set("$skip", page)
+		}
+	} else {
+		q.Set("$orderby", "receivedDateTime desc")
+		if f := filters.filter(); f != "" {
+			q.Set("$filter", f)
 		}
-		return exitcode.Success
 	}
 
-	return rt.writeJSON(map[string]any{"items": resp.Value, "next_page": next})
+	return rt.writeList(id, path, q, *list, func(it map[string]any) string {
+		idv, _ := it["id"].(string)
+		subj, _ := it["subject"].(string)
+		recv, _ := it["receivedDateTime"].(string)
+		return fmt.Sprintf("%s\t%s\t%s", idv, recv, strings.ReplaceAll(subj, "\t", " "))
+	}, nil)
 }
 
 func runMailGet(rt *runtimeState, id identityContext, args []string) int {
@@ -130,13 +260,19 @@ func runMailGet(rt *runtimeState, id identityContext, args []string) int {
 	q := url.Values{}
 	q.Set("$select", "id,subject,from,toRecipients,ccRecipients,bccRecipients,body,bodyPreview,receivedDateTime,sentDateTime,isRead,internetMessageId")
 	var out map[string]any
-	_, err := rt.graphRequest(id, "GET", "/v1.0/me/messages/"+url.PathEscape(msgID), q, nil, &out)
+	_, err := rt.graphRequest(id, "GET", messagePath(id, msgID), q, nil, &out)
 	if err != nil {
 		return rt.failErr(err)
 	}
 	return rt.writeJSON(out)
 }
 
+// mailMIMEMax is Graph's 4MB request limit, which applies to the base64
+// encoded MIME body of sendMail.
+const mailMIMEMax = 4 << 20
+
+const mailSendUsage = "Usage: mo mail send --to <emails> --subject <text> (--body <text> | --body-file PATH|-) [--cc ...] [--bcc ...] [--body-html] [--attach PATH]... [--mailbox ADDRESS [--on-behalf]] | mo mail send --mime FILE|- [--mailbox ADDRESS]"
+
 func runMailSend(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("mail send", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
@@ -145,48 +281,187 @@ func runMailSend(rt *runtimeState, id identityContext, args []string) int {
 	bcc := fs.String("bcc", "", "Comma-separated bcc recipients")
 	subject := fs.String("subject", "", "Email subject")
 	body := fs.String("body", "", "Email body")
+	bodyFile := fs.String("body-file", "", "Read the body from a file, or - for stdin")
 	html := fs.Bool("body-html", false, "Send body as HTML")
 	saveSent := fs.Bool("save-to-sent", true, "Save to sent items")
+	mimeFile := fs.String("mime", "", "Send a prebuilt MIME message from a file, or - for stdin")
+	onBehalf := fs.Bool("on-behalf", false, "With --mailbox, send on behalf of the mailbox instead of as it")
+	var attach stringList
+	fs.Var(&attach, "attach", "File to attach (repeatable)")
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid mail send flags", "Usage: mo mail send --to <emails> --subject <text> --body <text>"))
+		return rt.failErr(usageError("invalid mail send flags", mailSendUsage))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("mail send does not take positional arguments", "Run 'mo mail send --help'."))
 	}
+	// Send as goes through the shared mailbox itself. Send on behalf goes
+	// through the user's own mailbox with the shared one as "from", and
+	// recipients see "user on behalf of mailbox".
+	from := id.Mailbox
+	mode := ""
+	switch {
+	case *onBehalf && from == "":
+		return rt.failErr(usageError("--on-behalf requires --mailbox", mailSendUsage))
+	case *onBehalf:
+		mode = "on-behalf"
+		id.Mailbox, id.Scopes = "", nil
+	case from != "":
+		mode = "send-as"
+		id.Scopes = append(id.Scopes, "Mail.Send.Shared")
+	}
+
+	if strings.TrimSpace(*mimeFile) != "" {
+		conflict := ""
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name != "mime" && conflict == "" {
+				conflict = f.Name
+			}
+		})
+		if conflict != "" {
+			return rt.failErr(usageError(fmt.Sprintf("--%s cannot be combined with --mime", conflict), "Recipients, subject, body and attachments come from the MIME message."))
+		}
+		encoded, err := loadMIMEMessage(strings.TrimSpace(*mimeFile))
+		if err != nil {
+			return rt.failErr(err)
+		}
+		if _, err := rt.graphRequest(id, "POST", mailRoot(id)+"/sendMail", nil, textBody(encoded), nil); err != nil {
+			return rt.failErr(err)
+		}
+		out := map[string]any{"status": "sent", "mime": true}
+		if mode != "" {
+			out["mailbox"], out["mode"] = from, mode
+		}
+		return rt.writeJSON(out)
+	}
+
 	if strings.TrimSpace(*to) == "" {
-		return rt.failErr(usageError("--to is required", "Usage: mo mail send --to <emails> --subject <text> --body <text>"))
+		return rt.failErr(usageError("--to is required", mailSendUsage))
 	}
 	if strings.TrimSpace(*subject) == "" {
-		return rt.failErr(usageError("--subject is required", "Usage: mo mail send --to <emails> --subject <text> --body <text>"))
+		return rt.failErr(usageError("--subject is required", mailSendUsage))
+	}
+	if strings.TrimSpace(*bodyFile) != "" {
+		if strings.TrimSpace(*body) != "" {
+			return rt.failErr(usageError("pass either --body or --body-file, not both", mailSendUsage))
+		}
+		content, err := readBodyFile(strings.TrimSpace(*bodyFile))
+		if err != nil {
+			return rt.failErr(err)
+		}
+		*body = content
 	}
 	if strings.TrimSpace(*body) == "" {
-		return rt.failErr(usageError("--body is required", "Usage: mo mail send --to <emails> --subject <text> --body <text>"))
-	}
-
-	payload := map[string]any{
-		"message": map[string]any{
-			"subject": *subject,
-			"body": map[string]any{
-				"contentType": func() string {
-					if *html {
-						return "HTML"
-					}
-					return "Text"
-				}(),
-				"content": *body,
-			},
-			"toRecipients":  emailRecipients(*to),
-			"ccRecipients":  emailRecipients(*cc),
-			"bccRecipients": emailRecipients(*bcc),
-		},
-		"saveToSentItems": *saveSent,
-	}
-
-	_, err := rt.graphRequest(id, "POST", "/v1.0/me/sendMail", nil, payload, nil)
+		return rt.failErr(usageError("--body or --body-file is required", mailSendUsage))
+	}
+
+	atts, err := loadMailAttachments(attach)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if !attachmentsFitInline(atts) {
+		// Large attachments go through a draft, in the shared mailbox for
+		// send as and in the user's own one otherwise.
+		if mode == "send-as" {
+			id.Scopes = append(id.Scopes, "Mail.ReadWrite.Shared")
+		} else {
+			id.Scopes = append(id.Scopes, "Mail.ReadWrite")
+		}
+	}
+
+	message := map[string]any{
+		"subject":       *subject,
+		"body":          mailBody(*body, *html),
+		"toRecipients":  emailRecipients(*to),
+		"ccRecipients":  emailRecipients(*cc),
+		"bccRecipients": emailRecipients(*bcc),
+	}
+	if mode == "on-behalf" {
+		message["from"] = map[string]any{"emailAddress": map[string]any{"address": from}}
+	}
+
+	if attachmentsFitInline(atts) {
+		if len(atts) > 0 {
+			inline, err := inlineAttachments(atts)
+			if err != nil {
+				return rt.failErr(err)
+			}
+			message["attachments"] = inline
+		}
+		payload := map[string]any{
+			"message":         message,
+			"saveToSentItems": *saveSent,
+		}
+		_, err = rt.graphRequest(id, "POST", mailRoot(id)+"/sendMail", nil, payload, nil)
+	} else {
+		// Large attachments need a draft and upload sessions; drafts are
+		// always kept in Sent Items once sent.
+		if !*saveSent {
+			return rt.failErr(usageError("--save-to-sent=false is not supported with attachments over 3MB", "Drop --save-to-sent=false or send smaller attachments."))
+		}
+		err = rt.sendDraftWithAttachments(id, message, atts)
+	}
 	if err != nil {
 		return rt.failErr(err)
 	}
-	return rt.writeJSON(map[string]any{"status": "sent"})
+	out := map[string]any{"status": "sent"}
+	if len(atts) > 0 {
+		out["attachments"] = len(atts)
+	}
+	if mode != "" {
+		out["mailbox"], out["mode"] = from, mode
+	}
+	return rt.writeJSON(out)
+}
+
+func mailBody(content string, html bool) map[string]any {
+	contentType := "Text"
+	if html {
+		contentType = "HTML"
+	}
+	return map[string]any{"contentType": contentType, "content": content}
+}
+
+// readBodyFile reads a message body from path, or from stdin for "-", so
+// long bodies stay out of argv and process listings.
+func readBodyFile(path string) (string, error) {
+	data, err := readInput("--body-file", path, 0)
+	return string(data), err
+}
+
+// loadMIMEMessage reads an RFC 822 message and returns it base64 encoded, the
+// form sendMail expects with Content-Type text/plain. Input that is already
+// base64 is passed through.
+func loadMIMEMessage(path string) (string, error) {
+	data, err := readInput("--mime", path, 0)
+	if err != nil {
+		return "", err
+	}
+	raw := data
+	compact := strings.Join(strings.Fields(string(data)), "")
+	if decoded, err := base64.StdEncoding.DecodeString(compact); err == nil {
+		raw = decoded
+	}
+	if _, err := mail.ReadMessage(bytes.NewReader(raw)); err != nil {
+		return "", usageError("--mime is not a valid MIME message", err.Error())
+	}
+	encoded := base64.StdEncoding.EncodeToString(raw)
+	if len(encoded) > mailMIMEMax {
+		return "", usageError("MIME message is too large for sendMail", "Graph accepts up to 4MB per request; use --attach for large files.")
+	}
+	return encoded, nil
+}
+
+// mailRoot is the Graph path of the mailbox mail commands act on: the
+// signed-in user's own, or the one selected with --mailbox.
+func mailRoot(id identityContext) string {
+	if id.Mailbox == "" {
+		return "/v1.0/me"
+	}
+	return "/v1.0/users/" + url.PathEscape(id.Mailbox)
+}
+
+func messagePath(id identityContext, msgID string) string {
+	return mailRoot(id) + "/messages/" + url.PathEscape(msgID)
 }
 
 func emailRecipients(csv string) []map[string]any {
@@ -0,0 +1,281 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const draftSelect = "id,subject,toRecipients,ccRecipients,bccRecipients,hasAttachments,lastModifiedDateTime,webLink"
+
+func runMailDraft(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
+		return exitcode.Success
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	rest := args[1:]
+	switch sub {
+	case "create":
+		return runMailDraftCreate(rt, id, rest)
+	case "update":
+		return runMailDraftUpdate(rt, id, rest)
+	case "list":
+		return runMailDraftList(rt, id, rest)
+	case "send":
+		return runMailDraftSend(rt, id, rest)
+	case "delete":
+		return runMailDraftDelete(rt, id, rest)
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown mail draft subcommand %q", sub), "Run 'mo mail help' for usage."))
+	}
+}
+
+type mailComposeFlags struct {
+	to, cc, bcc, subject, body, bodyFile *string
+	html                                 *bool
+	attach                               stringList
+}
+
+func addMailComposeFlags(fs *flag.FlagSet) *mailComposeFlags {
+	f := &mailComposeFlags{}
+	f.to = fs.String("to", "", "Comma-separated recipients")
+	f.cc = fs.String("cc", "", "Comma-separated cc recipients")
+	f.bcc = fs.String("bcc", "", "Comma-separated bcc recipients")
+	f.subject = fs.String("subject", "", "Email subject")
+	f.body = fs.String("body", "", "Email body")
+	f.bodyFile = fs.String("body-file", "", "Read the body from a file, or - for stdin")
+	f.html = fs.Bool("body-html", false, "Body is HTML")
+	fs.Var(&f.attach, "attach", "File to attach (repeatable)")
+	return f
+}
+
+// loadBody replaces --body with the contents of --body-file, if given.
+func (f *mailComposeFlags) loadBody() error {
+	path := strings.TrimSpace(*f.bodyFile)
+	if path == "" {
+		return nil
+	}
+	if *f.body != "" {
+		return usageError("pass either --body or --body-file, not both", "Use --body-file for long or sensitive bodies.")
+	}
+	content, err := readBodyFile(path)
+	if err != nil {
+		return err
+	}
+	*f.body = content
+	return nil
+}
+
+// message builds the Graph message fields for flags in set. A nil set means
+// every field.
+func (f *mailComposeFlags) message(set map[string]bool) map[string]any {
+	has := func(name string) bool { return set == nil || set[name] }
+	m := map[string]any{}
+	if has("to") {
+		m["toRecipients"] = emailRecipients(*f.to)
+	}
+	if has("cc") {
+		m["ccRecipients"] = emailRecipients(*f.cc)
+	}
+	if has("bcc") {
+		m["bccRecipients"] = emailRecipients(*f.bcc)
+	}
+	if has("subject") {
+		m["subject"] = *f.subject
+	}
+	if has("body") || has("body-file") {
+		m["body"] = mailBody(*f.body, *f.html)
+	}
+	return m
+}
+
+func draftOutput(draft map[string]any, extra map[string]any) map[string]any {
+	out := map[string]any{
+		"id":       asString(draft["id"]),
+		"subject":  asString(draft["subject"]),
+		"web_link": asString(draft["webLink"]),
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	return out
+}
+
+func runMailDraftCreate(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail draft create [--to <emails>] [--subject <text>] [--body <text> | --body-file PATH|-] [--cc ...] [--bcc ...] [--body-html] [--attach PATH]..."
+	fs := flag.NewFlagSet("mail draft create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	compose := addMailComposeFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return rt.failErr(usageError("invalid mail draft create flags", usage))
+	}
+	if fs.NArg() != 0 {
+		return rt.failErr(usageError("mail draft create does not take positional arguments", usage))
+	}
+	if err := compose.loadBody(); err != nil {
+		return rt.failErr(err)
+	}
+	atts, err := loadMailAttachments(compose.attach)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	var draft map[string]any
+	if _, err := rt.graphRequest(id, http.MethodPost, mailRoot(id)+"/messages", nil, compose.message(nil), &draft); err != nil {
+		return rt.failErr(err)
+	}
+	draftID := asString(draft["id"])
+	if err := rt.addMessageAttachments(id, messagePath(id, draftID), atts); err != nil {
+		// Keep the draft: the human reviewer can still see and fix it, so
+		// say which one it is.
+		var ae *appError
+		if !errors.As(err, &ae) {
+			err = transientError("failed to attach files", err.Error())
+			_ = errors.As(err, &ae)
+		}
+		ae.Hint = strings.TrimSpace(ae.Hint + fmt.Sprintf(" The draft was created as %s; add the files with 'mo mail draft update %s --attach PATH' or remove it with 'mo mail draft delete %s'.", draftID, draftID, draftID))
+		return rt.failErr(ae)
+	}
+	return rt.writeJSON(draftOutput(draft, map[string]any{"created": true, "attachments": len(atts)}))
+}
+
+func runMailDraftUpdate(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail draft update <draft-id> [--to ...] [--cc ...] [--bcc ...] [--subject ...] [--body ... | --body-file PATH|-] [--body-html] [--attach PATH]... [--remove-attachment ID]..."
+	fs := flag.NewFlagSet("mail draft update", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	compose := addMailComposeFlags(fs)
+	var remove stringList
+	fs.Var(&remove, "remove-attachment", "Attachment id to remove (repeatable)")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid mail draft update flags", usage))
+	}
+	if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+		return rt.failErr(usageError("draft id is required", usage))
+	}
+	draftID := strings.TrimSpace(fs.Arg(0))
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if len(set) == 0 {
+		return rt.failErr(usageError("nothing to update", usage))
+	}
+	if set["body-html"] && !set["body"] && !set["body-file"] {
+		return rt.failErr(usageError("--body-html requires --body or --body-file", usage))
+	}
+	if err := compose.loadBody(); err != nil {
+		return rt.failErr(err)
+	}
+	atts, err := loadMailAttachments(compose.attach)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	draftPath := messagePath(id, draftID)
+	var draft map[string]any
+	if patch := compose.message(set); len(patch) > 0 {
+		if _, err := rt.graphRequest(id, http.MethodPatch, draftPath, nil, patch, &draft); err != nil {
+			return rt.failErr(err)
+		}
+	}
+	for _, attID := range remove {
+		if _, err := rt.graphRequest(id, http.MethodDelete, draftPath+"/attachments/"+url.PathEscape(strings.TrimSpace(attID)), nil, nil, nil); err != nil {
+			return rt.failErr(err)
+		}
+	}
+	if err := rt.addMessageAttachments(id, draftPath, atts); err != nil {
+		return rt.failErr(err)
+	}
+	if draft == nil {
+		q := url.Values{}
+		q.Set("$select", draftSelect)
+		if _, err := rt.graphRequest(id, http.MethodGet, draftPath, q, nil, &draft); err != nil {
+			return rt.failErr(err)
+		}
+	}
+	return rt.writeJSON(draftOutput(draft, map[string]any{
+		"updated":             true,
+		"attachments_added":   len(atts),
+		"attachments_removed": len(remove),
+	}))
+}
+
+func runMailDraftList(rt *runtimeState, id identityContext, args []string) int {
+	fs := flag.NewFlagSet("mail draft list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	list := addListFlags(fs, 20)
+	if err := fs.Parse(args); err != nil {
+		return rt.failErr(usageError("invalid mail draft list flags", "Usage: mo mail draft list [--max N] [--page TOKEN] [--all [--limit N]]"))
+	}
+	if fs.NArg() != 0 {
+		return rt.failErr(usageError("mail draft list does not take positional arguments", "Usage: mo mail draft list [--max N] [--page TOKEN] [--all [--limit N]]"))
+	}
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
+	}
+
+	q := url.Values{}
+	list.apply(q)
+	q.Set("$orderby", "lastModifiedDateTime desc")
+	q.Set("$select", draftSelect)
+	return rt.writeList(id, mailRoot(id)+"/mailFolders/drafts/messages", q, *list, func(it map[string]any) string {
+		return fmt.Sprintf("%s\t%s\t%s", asString(it["id"]), asString(it["lastModifiedDateTime"]), strings.ReplaceAll(asString(it["subject"]), "\t", " "))
+	}, nil)
+}
+
+func runMailDraftSend(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return rt.failErr(usageError("draft id is required", "Usage: mo mail draft send <draft-id>"))
+	}
+	draftID := strings.TrimSpace(args[0])
+	if err := rt.requireDraft(id, draftID); err != nil {
+		return rt.failErr(err)
+	}
+	if _, err := rt.graphRequest(id, http.MethodPost, messagePath(id, draftID)+"/send", nil, nil, nil); err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{"status": "sent", "id": draftID})
+}
+
+func runMailDraftDelete(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return rt.failErr(usageError("draft id is required", "Usage: mo mail draft delete <draft-id>"))
+	}
+	draftID := strings.TrimSpace(args[0])
+	if err := rt.requireDraft(id, draftID); err != nil {
+		return rt.failErr(err)
+	}
+	ok, err := confirmAction(rt, "Delete draft?")
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if !ok {
+		return rt.writeJSON(map[string]any{"deleted": false, "id": draftID})
+	}
+	if _, err := rt.graphRequest(id, http.MethodDelete, messagePath(id, draftID), nil, nil, nil); err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{"deleted": true, "id": draftID})
+}
+
+// requireDraft refuses message ids that are not drafts, so draft send and
+// draft delete cannot act on received or sent mail.
+func (rt *runtimeState) requireDraft(id identityContext, msgID string) error {
+	q := url.Values{}
+	q.Set("$select", "isDraft")
+	var msg struct {
+		IsDraft bool `json:"isDraft"`
+	}
+	if _, err := rt.graphRequest(id, http.MethodGet, messagePath(id, msgID), q, nil, &msg); err != nil {
+		return err
+	}
+	if !msg.IsDraft {
+		return usageError(fmt.Sprintf("message %s is not a draft", msgID), "Use mo mail draft list to find draft ids, or mo mail delete for other messages.")
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+func TestMailComposeFlagsPatchOnlySetFields(t *testing.T) {
+	fs := flag.NewFlagSet("t", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	compose := addMailComposeFlags(fs)
+	if err := fs.Parse([]string{"--subject", "v2", "--cc", "a@example.com"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	patch := compose.message(set)
+	if len(patch) != 2 || patch["subject"] != "v2" {
+		t.Fatalf("unexpected patch %v", patch)
+	}
+	if _, ok := patch["body"]; ok {
+		t.Fatalf("body must not be patched when --body is not given")
+	}
+
+	full := compose.message(nil)
+	if _, ok := full["toRecipients"]; !ok {
+		t.Fatalf("expected full message to include every field, got %v", full)
+	}
+}
+
+func TestMailDraftCreateReportsDraftWhenAttachFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1.0/me/messages" {
+			_, _ = w.Write([]byte(`{"id":"draft-1","subject":"Report"}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":"ErrorAccessDenied","message":"denied"}}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("numbers"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runMailDraftCreate(rt, identityContext{}, []string{"--subject", "Report", "--attach", path}); code == 0 {
+		t.Fatalf("expected attach failure to fail the command")
+	}
+	if msg := rt.stderr.(*bytes.Buffer).String(); !strings.Contains(msg, "draft-1") {
+		t.Fatalf("expected the draft id in the error, got %s", msg)
+	}
+}
+
+func TestMailDraftSendAndDeleteRefuseNonDrafts(t *testing.T) {
+	var mutations []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			mutations = append(mutations, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"inbox-1","isDraft":false}`))
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	rt.globals.Force = true
+	if code := runMailDraftSend(rt, identityContext{}, []string{"inbox-1"}); code != exitcode.UsageError {
+		t.Fatalf("expected usage error for draft send, got %d", code)
+	}
+	if code := runMailDraftDelete(rt, identityContext{}, []string{"inbox-1"}); code != exitcode.UsageError {
+		t.Fatalf("expected usage error for draft delete, got %d", code)
+	}
+	if len(mutations) != 0 {
+		t.Fatalf("expected no send or delete requests, got %v", mutations)
+	}
+	if msg := rt.stderr.(*bytes.Buffer).String(); !strings.Contains(msg, "not a draft") {
+		t.Fatalf("expected a not-a-draft error, got %s", msg)
+	}
+}
@@ -0,0 +1,386 @@
+package app
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const mailExportUsage = "Usage: mo mail export <message-id> --out FILE.eml | mo mail export --folder FOLDER --format mbox|eml-dir --out PATH [--from RFC3339] [--to RFC3339] [--restart]"
+
+func runMailExport(rt *runtimeState, id identityContext, args []string) int {
+	fs := flag.NewFlagSet("mail export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	outPath := fs.String("out", "", "Output .eml file, mbox file, or directory")
+	folder := fs.String("folder", "", "Folder to export in bulk")
+	format := fs.String("format", "", "Bulk export format: mbox|eml-dir")
+	from := fs.String("from", "", "Only messages received at or after this time (RFC3339)")
+	to := fs.String("to", "", "Only messages received at or before this time (RFC3339)")
+	restart := fs.Bool("restart", false, "Ignore the checkpoint and export everything again")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid mail export flags", mailExportUsage))
+	}
+	dest := strings.TrimSpace(*outPath)
+	if dest == "" {
+		return rt.failErr(usageError("--out is required", mailExportUsage))
+	}
+
+	if fs.NArg() > 0 {
+		if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+			return rt.failErr(usageError("export takes one message id", mailExportUsage))
+		}
+		if strings.TrimSpace(*folder) != "" || strings.TrimSpace(*format) != "" {
+			return rt.failErr(usageError("--folder and --format are for bulk export; pass either a message id or --folder", mailExportUsage))
+		}
+		return rt.exportMessage(id, strings.TrimSpace(fs.Arg(0)), dest)
+	}
+
+	if strings.TrimSpace(*folder) == "" {
+		return rt.failErr(usageError("a message id or --folder is required", mailExportUsage))
+	}
+	exp := mailExport{
+		Mailbox: id.Mailbox,
+		Folder:  strings.TrimSpace(*folder),
+		Format:  strings.ToLower(strings.TrimSpace(*format)),
+		From:    strings.TrimSpace(*from),
+		To:      strings.TrimSpace(*to),
+	}
+	if exp.Format != "mbox" && exp.Format != "eml-dir" {
+		return rt.failErr(usageError("--format must be mbox or eml-dir", mailExportUsage))
+	}
+	for flagName, v := range map[string]string{"--from": exp.From, "--to": exp.To} {
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return rt.failErr(usageError("invalid "+flagName+" timestamp", "Use RFC3339 format, e.g. 2026-02-18T00:00:00Z."))
+		}
+	}
+	return rt.exportFolder(id, exp, dest, *restart)
+}
+
+func (rt *runtimeState) exportMessage(id identityContext, msgID, dest string) int {
+	if st, err := os.Stat(dest); err == nil && st.IsDir() {
+		dest = filepath.Join(dest, emlFileName("", msgID))
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return rt.failErr(transientError("failed to create output directory", err.Error()))
+	}
+	n, err := rt.writeMIMEFile(id, msgID, dest)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{"exported": true, "id": msgID, "path": dest, "bytes": n})
+}
+
+// mailExport describes a bulk export. It is stored as the first line of the
+// checkpoint so a resumed run cannot silently mix different exports.
+type mailExport struct {
+	Mailbox string `json:"mailbox,omitempty"`
+	Folder  string `json:"folder"`
+	Format  string `json:"format"`
+	From    string `json:"from,omitempty"`
+	To      string `json:"to,omitempty"`
+}
+
+// exportCheckpoint is an append-only log of exported message ids. For mbox
+// each line also records the file size after the message, so an interrupted
+// append can be cut off on resume.
+type exportCheckpoint struct {
+	path string
+	done map[string]bool
+	size int64
+	f    *os.File
+}
+
+func checkpointPath(exp mailExport, dest string) string {
+	if exp.Format == "eml-dir" {
+		return filepath.Join(dest, ".mo-export-checkpoint")
+	}
+	return dest + ".mo-export-checkpoint"
+}
+
+func openExportCheckpoint(path string, exp mailExport, restart bool) (*exportCheckpoint, error) {
+	cp := &exportCheckpoint{path: path, done: map[string]bool{}}
+	if restart {
+		_ = os.Remove(path)
+	}
+	if f, err := os.Open(path); err == nil {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64<<10), 1<<20)
+		first := true
+		for sc.Scan() {
+			line := sc.Text()
+			if first {
+				first = false
+				var saved mailExport
+				if err := json.Unmarshal([]byte(line), &saved); err != nil || saved != exp {
+					_ = f.Close()
+					return nil, usageError("checkpoint belongs to a different export", fmt.Sprintf("Use the same --folder/--format/--from/--to as before, or --restart to start over (%s).", path))
+				}
+				continue
+			}
+			msgID, size, _ := strings.Cut(line, "\t")
+			if msgID == "" {
+				continue
+			}
+			cp.done[msgID] = true
+			if size != "" {
+				_, _ = fmt.Sscan(size, &cp.size)
+			}
+		}
+		_ = f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, transientError("failed to read export checkpoint", err.Error())
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, transientError("failed to read export checkpoint", err.Error())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, transientError("failed to create output directory", err.Error())
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, transientError("failed to open export checkpoint", err.Error())
+	}
+	if st, err := f.Stat(); err == nil && st.Size() == 0 {
+		header, _ := json.Marshal(exp)
+		if _, err := f.Write(append(header, '\n')); err != nil {
+			_ = f.Close()
+			return nil, transientError("failed to write export checkpoint", err.Error())
+		}
+	}
+	cp.f = f
+	return cp, nil
+}
+
+func (cp *exportCheckpoint) record(msgID string, size int64) error {
+	line := msgID + "\n"
+	if size >= 0 {
+		line = fmt.Sprintf("%s\t%d\n", msgID, size)
+		cp.size = size
+	}
+	cp.done[msgID] = true
+	if _, err := cp.f.WriteString(line); err != nil {
+		return transientError("failed to write export checkpoint", err.Error())
+	}
+	return nil
+}
+
+func (cp *exportCheckpoint) Close() error {
+	return cp.f.Close()
+}
+
+// exportFolder pages through the folder oldest first and writes each message
+// as it arrives, so memory use does not grow with the folder. Messages listed
+// in the checkpoint are skipped, which makes re-runs resume or pick up only
+// new mail.
+func (rt *runtimeState) exportFolder(id identityContext, exp mailExport, dest string, restart bool) int {
+	folderID, err := rt.resolveMailFolder(id, exp.Folder)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	cp, err := openExportCheckpoint(checkpointPath(exp, dest), exp, restart)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	defer cp.Close()
+
+	var mbox *os.File
+	if exp.Format == "mbox" {
+		if st, err := os.Stat(dest); err == nil && st.Size() > 0 && len(cp.done) == 0 && !restart {
+			return rt.failErr(usageError(fmt.Sprintf("%s already exists and has no export checkpoint", dest), "Pick a new --out path, or pass --restart to overwrite it."))
+		}
+		if !restart && cp.size > 0 {
+			// A missing or shorter file would be padded with NULs by Truncate
+			// while the checkpoint keeps skipping the lost messages.
+			if st, err := os.Stat(dest); err != nil || st.Size() < cp.size {
+				return rt.failErr(usageError(fmt.Sprintf("export checkpoint does not match %s", dest), "The mbox file was moved, deleted or truncated since the last run; pass --restart to export again."))
+			}
+		}
+		flags := os.O_CREATE | os.O_WRONLY
+		if restart {
+			flags |= os.O_TRUNC
+		}
+		mbox, err = os.OpenFile(dest, flags, 0o644)
+		if err != nil {
+			return rt.failErr(transientError("failed to open mbox file", err.Error()))
+		}
+		defer mbox.Close()
+		// Drop anything after the last checkpointed message: a partial
+		// append from an interrupted run.
+		if err := mbox.Truncate(cp.size); err != nil {
+			return rt.failErr(transientError("failed to trim mbox file", err.Error()))
+		}
+		if _, err := mbox.Seek(cp.size, io.SeekStart); err != nil {
+			return rt.failErr(transientError("failed to seek mbox file", err.Error()))
+		}
+	}
+
+	q := url.Values{}
+	q.Set("$top", "100")
+	q.Set("$select", "id,receivedDateTime")
+	q.Set("$orderby", "receivedDateTime asc")
+	filters := make([]string, 0, 2)
+	if exp.From != "" {
+		filters = append(filters, "receivedDateTime ge "+exp.From)
+	}
+	if exp.To != "" {
+		filters = append(filters, "receivedDateTime le "+exp.To)
+	}
+	if len(filters) > 0 {
+		q.Set("$filter", strings.Join(filters, " and "))
+	}
+
+	exported, skipped := 0, 0
+	size := cp.size
+	_, err = rt.listPages(id, mailFolderPath(id, folderID)+"/messages", q, listOptions{All: true}, func(items []map[string]any) error {
+		for _, it := range items {
+			msgID := asString(it["id"])
+			if cp.done[msgID] {
+				skipped++
+				continue
+			}
+			received := asString(it["receivedDateTime"])
+			if exp.Format == "mbox" {
+				n, err := rt.appendMbox(id, mbox, msgID, received)
+				if err != nil {
+					_ = mbox.Truncate(size)
+					return err
+				}
+				size += n
+				if err := cp.record(msgID, size); err != nil {
+					return err
+				}
+			} else {
+				if _, err := rt.writeMIMEFile(id, msgID, filepath.Join(dest, emlFileName(received, msgID))); err != nil {
+					return err
+				}
+				if err := cp.record(msgID, -1); err != nil {
+					return err
+				}
+			}
+			exported++
+		}
+		return nil
+	})
+	if err != nil {
+		if ae, ok := err.(*appError); ok {
+			ae.Hint = strings.TrimSpace(ae.Hint + " Re-run the same command to resume; exported messages are skipped.")
+		}
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{
+		"exported":   exported,
+		"skipped":    skipped,
+		"format":     exp.Format,
+		"folder":     exp.Folder,
+		"path":       dest,
+		"checkpoint": cp.path,
+	})
+}
+
+// writeMIMEFile downloads a message's MIME content to dest via a .part file.
+func (rt *runtimeState) writeMIMEFile(id identityContext, msgID, dest string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return 0, transientError("failed to create output directory", err.Error())
+	}
+	tmp := dest + ".part"
+	n, _, err := rt.downloadDriveContent(id, messagePath(id, msgID)+"/$value", tmp, 0, "")
+	if err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return 0, transientError("failed to place output file", err.Error())
+	}
+	return n, nil
+}
+
+// appendMbox streams one message into an mboxrd file: a "From " separator
+// line, LF line endings, and ">" added to body lines that already start with
+// any number of ">" followed by "From ".
+func (rt *runtimeState) appendMbox(id identityContext, w io.Writer, msgID, received string) (int64, error) {
+	resp, err := rt.driveRawRequest(id, http.MethodGet, messagePath(id, msgID)+"/$value", nil, nil, nil, "", -1)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+		return 0, graphErrorFromBody(resp.StatusCode, body)
+	}
+
+	at, err := time.Parse(time.RFC3339, received)
+	if err != nil {
+		at = time.Now()
+	}
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+	_, _ = fmt.Fprintf(cw, "From MAILER-DAEMON %s\n", at.UTC().Format(time.ANSIC))
+	if err := writeMboxBody(cw, resp.Body); err != nil {
+		return 0, transientError("failed to export message", err.Error())
+	}
+	if err := bw.Flush(); err != nil {
+		return 0, transientError("failed to write mbox file", err.Error())
+	}
+	return cw.n, nil
+}
+
+func writeMboxBody(w io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+				line = ">" + line
+			}
+			if _, werr := io.WriteString(w, line+"\n"); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// Blank line before the next "From " separator.
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// emlFileName sorts by received time and stays unique per message id.
+func emlFileName(received, msgID string) string {
+	digest := sha256.Sum256([]byte(msgID))
+	prefix := "message"
+	if t, err := time.Parse(time.RFC3339, received); err == nil {
+		prefix = t.UTC().Format("20060102T150405Z")
+	}
+	return prefix + "-" + hex.EncodeToString(digest[:6]) + ".eml"
+}
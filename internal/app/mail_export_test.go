@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMboxBodyEscapesFromLines(t *testing.T) {
+	var buf bytes.Buffer
+	in := "Subject: hi\r\n\r\nFrom here on\r\n>From quoted\r\nplain"
+	if err := writeMboxBody(&buf, strings.NewReader(in)); err != nil {
+		t.Fatalf("writeMboxBody: %v", err)
+	}
+	want := "Subject: hi\n\n>From here on\n>>From quoted\nplain\n\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected mbox body:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestMailExportMboxResumesFromCheckpoint(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+	failM2 := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1.0/me/mailFolders/inbox/messages":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"value":[{"id":"m1","receivedDateTime":"2026-02-01T10:00:00Z"},{"id":"m2","receivedDateTime":"2026-02-02T10:00:00Z"}]}`))
+		case "/v1.0/me/messages/m1/$value":
+			_, _ = w.Write([]byte("Subject: one\r\n\r\nbody one\r\n"))
+		case "/v1.0/me/messages/m2/$value":
+			if failM2 {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":"ErrorItemNotFound","message":"gone"}}`))
+				return
+			}
+			_, _ = w.Write([]byte("Subject: two\r\n\r\nbody two\r\n"))
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "inbox.mbox")
+	args := []string{"--folder", "inbox", "--format", "mbox", "--out", dest}
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runMailExport(rt, identityContext{}, args); code == 0 {
+		t.Fatalf("expected failure while m2 is unavailable")
+	}
+
+	failM2 = false
+	out.Reset()
+	if code := runMailExport(rt, identityContext{}, args); code != 0 {
+		t.Fatalf("expected resume to succeed, got %d: %s", code, out.String())
+	}
+	if !strings.Contains(out.String(), `"skipped":1`) || !strings.Contains(out.String(), `"exported":1`) {
+		t.Fatalf("expected one skipped and one exported, got %s", out.String())
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("read mbox: %v", err)
+	}
+	if strings.Count(string(data), "From MAILER-DAEMON ") != 2 || !strings.Contains(string(data), "body two") {
+		t.Fatalf("unexpected mbox content:\n%s", data)
+	}
+
+	// The checkpoint outlives a deleted archive; re-running must not pad a
+	// new file with NULs and skip the lost messages.
+	if err := os.Remove(dest); err != nil {
+		t.Fatalf("remove mbox: %v", err)
+	}
+	out.Reset()
+	if code := runMailExport(rt, identityContext{}, args); code == 0 {
+		t.Fatalf("expected a missing mbox with a checkpoint to fail, got %s", out.String())
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("expected no mbox to be created, got %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const mailFolderSelect = "id,displayName,parentFolderId,childFolderCount,unreadItemCount,totalItemCount"
+
+// wellKnownMailFolders maps accepted spellings (lower case, spaces removed)
+// to Graph's well-known folder names, which work anywhere a folder id does.
+var wellKnownMailFolders = map[string]string{
+	"inbox":                     "inbox",
+	"drafts":                    "drafts",
+	"sent":                      "sentitems",
+	"sentitems":                 "sentitems",
+	"deleted":                   "deleteditems",
+	"deleteditems":              "deleteditems",
+	"trash":                     "deleteditems",
+	"junk":                      "junkemail",
+	"junkemail":                 "junkemail",
+	"spam":                      "junkemail",
+	"archive":                   "archive",
+	"outbox":                    "outbox",
+	"clutter":                   "clutter",
+	"conversationhistory":       "conversationhistory",
+	"scheduled":                 "scheduled",
+	"searchfolders":             "searchfolders",
+	"recoverableitemsdeletions": "recoverableitemsdeletions",
+	"msgfolderroot":             "msgfolderroot",
+	"root":                      "msgfolderroot",
+}
+
+func wellKnownMailFolder(name string) (string, bool) {
+	v, ok := wellKnownMailFolders[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", ""))]
+	return v, ok
+}
+
+// looksLikeGraphID reports whether ref is shaped like an Exchange item id:
+// long, URL-safe base64 and free of spaces or path separators.
+func looksLikeGraphID(ref string) bool {
+	if len(ref) < 40 {
+		return false
+	}
+	for _, r := range ref {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '=', r == '+':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func mailFolderPath(id identityContext, folderID string) string {
+	return mailRoot(id) + "/mailFolders/" + url.PathEscape(folderID)
+}
+
+// resolveMailFolder turns a --folder value into something Graph accepts as a
+// folder id. Well-known names pass through; "id:" forces an id; anything
+// else is a display name path such as "Inbox/Projects/Alpha", walked one
+// level at a time from the mailbox root. A single name that matches no
+// folder but looks like an id is taken as one.
+func (rt *runtimeState) resolveMailFolder(id identityContext, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", usageError("folder is required", "Pass a folder id, a well-known name such as inbox, or a path like Inbox/Projects.")
+	}
+	if v, ok := strings.CutPrefix(ref, "id:"); ok {
+		return strings.TrimSpace(v), nil
+	}
+	if v, ok := wellKnownMailFolder(ref); ok {
+		return v, nil
+	}
+	segments := strings.Split(strings.Trim(ref, "/"), "/")
+	parent := "msgfolderroot"
+	for i, seg := range segments {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			return "", usageError(fmt.Sprintf("invalid folder path %q", ref), "Separate folder names with a single '/'.")
+		}
+		if i == 0 {
+			if v, ok := wellKnownMailFolder(seg); ok {
+				parent = v
+				continue
+			}
+		}
+		child, err := rt.findChildMailFolder(id, parent, seg)
+		if err != nil {
+			return "", err
+		}
+		if child == "" {
+			if len(segments) == 1 && looksLikeGraphID(ref) {
+				return ref, nil
+			}
+			return "", notFoundError(fmt.Sprintf("mail folder %q not found", strings.Join(segments[:i+1], "/")), "Run 'mo mail folders --recursive' to list folder paths.")
+		}
+		parent = child
+	}
+	return parent, nil
+}
+
+func (rt *runtimeState) findChildMailFolder(id identityContext, parentID, name string) (string, error) {
+	q := url.Values{}
+	q.Set("$filter", "displayName eq '"+strings.ReplaceAll(name, "'", "''")+"'")
+	q.Set("$select", "id,displayName")
+	var resp struct {
+		Value []struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"displayName"`
+		} `json:"value"`
+	}
+	if _, err := rt.graphRequest(id, http.MethodGet, mailFolderPath(id, parentID)+"/childFolders", q, nil, &resp); err != nil {
+		return "", err
+	}
+	for _, f := range resp.Value {
+		if strings.EqualFold(f.DisplayName, name) {
+			return f.ID, nil
+		}
+	}
+	return "", nil
+}
+
+func runMailFolders(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail folders [--parent FOLDER] [--recursive]"
+	fs := flag.NewFlagSet("mail folders", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	parent := fs.String("parent", "", "List children of this folder instead of the top level")
+	recursive := fs.Bool("recursive", false, "Include all nested folders")
+	if err := fs.Parse(args); err != nil {
+		return rt.failErr(usageError("invalid mail folders flags", usage))
+	}
+	if fs.NArg() != 0 {
+		return rt.failErr(usageError("mail folders does not take positional arguments", usage))
+	}
+
+	root := "msgfolderroot"
+	prefix := ""
+	if strings.TrimSpace(*parent) != "" {
+		resolved, err := rt.resolveMailFolder(id, *parent)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		root = resolved
+		prefix = strings.Trim(strings.TrimSpace(*parent), "/") + "/"
+	}
+
+	return rt.writeItems(func(each func([]map[string]any) error) (string, error) {
+		return "", rt.walkMailFolders(id, root, prefix, *recursive, each)
+	}, func(it map[string]any) string {
+		return fmt.Sprintf("%s\t%d\t%d\t%s", asString(it["path"]), asInt64(it["unreadItemCount"]), asInt64(it["totalItemCount"]), asString(it["id"]))
+	}, nil)
+}
+
+// walkMailFolders lists the children of parentID depth first, adding a
+// display name "path" to each folder.
+func (rt *runtimeState) walkMailFolders(id identityContext, parentID, prefix string, recursive bool, each func([]map[string]any) error) error {
+	q := url.Values{}
+	q.Set("$top", "100")
+	q.Set("$select", mailFolderSelect)
+	_, err := rt.listPages(id, mailFolderPath(id, parentID)+"/childFolders", q, listOptions{All: true}, func(items []map[string]any) error {
+		for _, it := range items {
+			it["path"] = prefix + asString(it["displayName"])
+			if err := each([]map[string]any{it}); err != nil {
+				return err
+			}
+			if recursive && asInt64(it["childFolderCount"]) > 0 {
+				if err := rt.walkMailFolders(id, asString(it["id"]), asString(it["path"])+"/", true, each); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func runMailFolder(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
+		return exitcode.Success
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	rest := args[1:]
+	switch sub {
+	case "create":
+		return runMailFolderCreate(rt, id, rest)
+	case "rename":
+		return runMailFolderRename(rt, id, rest)
+	case "delete":
+		return runMailFolderDelete(rt, id, rest)
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown mail folder subcommand %q", sub), "Run 'mo mail help' for usage."))
+	}
+}
+
+func runMailFolderCreate(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail folder create <name|parent/path/name> [--parent FOLDER]"
+	fs := flag.NewFlagSet("mail folder create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	parent := fs.String("parent", "", "Parent folder (default: top level)")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid mail folder create flags", usage))
+	}
+	if fs.NArg() != 1 || strings.Trim(strings.TrimSpace(fs.Arg(0)), "/") == "" {
+		return rt.failErr(usageError("folder name is required", usage))
+	}
+	name := strings.Trim(strings.TrimSpace(fs.Arg(0)), "/")
+	parentRef := strings.TrimSpace(*parent)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		if parentRef != "" {
+			return rt.failErr(usageError("pass either a folder path or --parent, not both", usage))
+		}
+		parentRef, name = name[:i], strings.TrimSpace(name[i+1:])
+	}
+
+	path := mailRoot(id) + "/mailFolders"
+	if parentRef != "" {
+		parentID, err := rt.resolveMailFolder(id, parentRef)
+		if err != nil {
+			return rt.failErr(err)
+		}
+		path = mailFolderPath(id, parentID) + "/childFolders"
+	}
+	var out map[string]any
+	if _, err := rt.graphRequest(id, http.MethodPost, path, nil, map[string]any{"displayName": name}, &out); err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{
+		"created":      true,
+		"id":           asString(out["id"]),
+		"display_name": asString(out["displayName"]),
+		"parent_id":    asString(out["parentFolderId"]),
+	})
+}
+
+func runMailFolderRename(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail folder rename <folder> <new-name>"
+	if len(args) != 2 || strings.TrimSpace(args[0]) == "" || strings.TrimSpace(args[1]) == "" {
+		return rt.failErr(usageError("folder and new name are required", usage))
+	}
+	folderID, err := rt.resolveMailFolder(id, args[0])
+	if err != nil {
+		return rt.failErr(err)
+	}
+	var out map[string]any
+	if _, err := rt.graphRequest(id, http.MethodPatch, mailFolderPath(id, folderID), nil, map[string]any{"displayName": strings.TrimSpace(args[1])}, &out); err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{
+		"updated":      true,
+		"id":           asString(out["id"]),
+		"display_name": asString(out["displayName"]),
+	})
+}
+
+func runMailFolderDelete(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail folder delete <folder>"
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return rt.failErr(usageError("folder is required", usage))
+	}
+	ref := strings.TrimSpace(args[0])
+	if _, ok := wellKnownMailFolder(ref); ok {
+		return rt.failErr(usageError(fmt.Sprintf("cannot delete well-known folder %q", ref), "Only folders you created can be deleted."))
+	}
+	folderID, err := rt.resolveMailFolder(id, ref)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	ok, err := confirmAction(rt, fmt.Sprintf("Delete folder %q and everything in it?", ref))
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if !ok {
+		return rt.writeJSON(map[string]any{"deleted": false, "id": folderID})
+	}
+	if _, err := rt.graphRequest(id, http.MethodDelete, mailFolderPath(id, folderID), nil, nil, nil); err != nil {
+		return rt.failErr(err)
+	}
+	return rt.writeJSON(map[string]any{"deleted": true, "id": folderID})
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveMailFolderShortcuts(t *testing.T) {
+	rt := &runtimeState{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, lookup: fakeEnv(nil)}
+	cases := map[string]string{
+		"Inbox":       "inbox",
+		"Sent Items":  "sentitems",
+		"trash":       "deleteditems",
+		"id:short-id": "short-id",
+	}
+	for in, want := range cases {
+		got, err := rt.resolveMailFolder(identityContext{}, in)
+		if err != nil || got != want {
+			t.Fatalf("resolveMailFolder(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestResolveMailFolderWalksPath(t *testing.T) {
+	children := map[string]map[string]string{
+		"/v1.0/me/mailFolders/inbox/childFolders":         {"displayName eq 'Projects'": "f-projects"},
+		"/v1.0/me/mailFolders/f-projects/childFolders":    {"displayName eq 'Alpha''s'": "f-alpha"},
+		"/v1.0/me/mailFolders/msgfolderroot/childFolders": {"displayName eq 'Quarterly_Reports-2024-Archive-Customers-EU'": "f-reports"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		filter := r.URL.Query().Get("$filter")
+		id, ok := children[r.URL.Path][filter]
+		if !ok {
+			_, _ = w.Write([]byte(`{"value":[]}`))
+			return
+		}
+		name := filter[len("displayName eq '") : len(filter)-1]
+		name = strings.ReplaceAll(name, "''", "'")
+		_, _ = w.Write([]byte(`{"value":[{"id":"` + id + `","displayName":"` + name + `"}]}`))
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	got, err := rt.resolveMailFolder(identityContext{}, "Inbox/Projects/Alpha's")
+	if err != nil || got != "f-alpha" {
+		t.Fatalf("expected f-alpha, got %q, %v", got, err)
+	}
+
+	// Long names that look like ids still match folders first; ids that
+	// match no name fall back to being used as ids.
+	got, err = rt.resolveMailFolder(identityContext{}, "Quarterly_Reports-2024-Archive-Customers-EU")
+	if err != nil || got != "f-reports" {
+		t.Fatalf("expected f-reports, got %q, %v", got, err)
+	}
+	longID := "AAMkAGI2TG93AAA-bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb_ccc="
+	got, err = rt.resolveMailFolder(identityContext{}, longID)
+	if err != nil || got != longID {
+		t.Fatalf("expected %q, got %q, %v", longID, got, err)
+	}
+
+	_, err = rt.resolveMailFolder(identityContext{}, "Inbox/Missing")
+	ae, ok := err.(*appError)
+	if !ok || ae.Code != "not_found" {
+		t.Fatalf("expected not_found error, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"strings"
+)
+
+// mailBoolFlags are the mail flags that take no value. Any other flag given
+// without "=" owns the next argument, which splitMailboxFlag passes through
+// untouched.
+var mailBoolFlags = map[string]bool{
+	"all": true, "body-html": true, "clear": true, "dry-run": true,
+	"has-attachments": true, "help": true, "h": true, "on-behalf": true,
+	"permanent": true, "prune": true, "read": true, "recursive": true,
+	"reset": true, "restart": true, "save-to-sent": true, "unread": true,
+}
+
+// splitMailboxFlag removes --mailbox ADDRESS (or --mailbox=ADDRESS) from
+// mail arguments, so every mail command accepts it without declaring it.
+// Values of other flags are skipped, so "--subject --mailbox" keeps
+// "--mailbox" as the subject.
+func splitMailboxFlag(args []string) (string, []string, error) {
+	mailbox := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			rest = append(rest, arg)
+			continue
+		}
+		if name != "mailbox" {
+			rest = append(rest, arg)
+			if !hasValue && !mailBoolFlags[name] && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, usageError("--mailbox requires an address", "Usage: mo mail <command> --mailbox shared@example.com ...")
+			}
+			i++
+			value = args[i]
+		}
+		mailbox = strings.TrimSpace(value)
+		if mailbox == "" {
+			return "", nil, usageError("--mailbox requires an address", "Usage: mo mail <command> --mailbox shared@example.com ...")
+		}
+	}
+	return mailbox, rest, nil
+}
+
+// sharedMailScopes returns the delegated scopes a mail command needs to act
+// on another user's mailbox. mail send adds Mail.Send.Shared (and
+// Mail.ReadWrite.Shared for large attachments) itself, since sending on
+// behalf goes through the user's own mailbox. Settings, automatic
+// replies, rules and categories have no shared scope; Exchange permissions
+// decide.
+func sharedMailScopes(sub string, rest []string) []string {
+	switch sub {
+	case "list", "search", "sync", "watch", "thread", "export", "get", "folders", "attachments", "attachment":
+		return []string{"Mail.Read.Shared"}
+	case "reply", "reply-all", "forward":
+		return []string{"Mail.ReadWrite.Shared", "Mail.Send.Shared"}
+	case "draft":
+		if len(rest) > 0 && strings.EqualFold(strings.TrimSpace(rest[0]), "send") {
+			return []string{"Mail.ReadWrite.Shared", "Mail.Send.Shared"}
+		}
+		return []string{"Mail.ReadWrite.Shared"}
+	case "move", "copy", "delete", "mark", "flag", "categorize", "folder":
+		return []string{"Mail.ReadWrite.Shared"}
+	default:
+		return nil
+	}
+}
+
+// ownMailScopes returns the scopes a mail command needs on the signed-in
+// user's own mailbox beyond Mail.Read. Accounts authorized before
+// Mail.ReadWrite became a default scope only granted Mail.Read, so asking for
+// it here turns a bare 403 into a consent hint. mail send and reply add it
+// themselves when they go through a draft.
+func ownMailScopes(sub string) []string {
+	switch sub {
+	case "draft", "move", "copy", "delete", "mark", "flag", "categorize", "folder":
+		return []string{"Mail.ReadWrite"}
+	default:
+		return nil
+	}
+}
+
+// resolveMailIdentity is resolveIdentity for mail commands, pointed at
+// mailbox when one is given. The signed-in user's own address counts as no
+// mailbox, so /me paths and scopes stay in use.
+func (rt *runtimeState) resolveMailIdentity(mailbox, sub string, rest []string) (identityContext, error) {
+	id, err := rt.resolveIdentity()
+	if err != nil {
+		return id, err
+	}
+	if mailbox == "" || strings.EqualFold(mailbox, id.Account) {
+		id.Scopes = ownMailScopes(sub)
+		return id, nil
+	}
+	id.Mailbox = mailbox
+	id.Scopes = sharedMailScopes(sub, rest)
+	return id, nil
+}
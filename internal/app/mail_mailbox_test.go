@@ -0,0 +1,203 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svaruag/mocli/internal/config"
+	"github.com/svaruag/mocli/internal/secrets"
+)
+
+func TestSplitMailboxFlag(t *testing.T) {
+	mailbox, rest, err := splitMailboxFlag([]string{"list", "--mailbox", "support@contoso.com", "--max", "5"})
+	if err != nil || mailbox != "support@contoso.com" || strings.Join(rest, " ") != "list --max 5" {
+		t.Fatalf("unexpected split %q %v %v", mailbox, rest, err)
+	}
+	mailbox, rest, _ = splitMailboxFlag([]string{"--mailbox=team@contoso.com", "get", "m1"})
+	if mailbox != "team@contoso.com" || strings.Join(rest, " ") != "get m1" {
+		t.Fatalf("unexpected split %q %v", mailbox, rest)
+	}
+	if _, _, err := splitMailboxFlag([]string{"list", "--mailbox"}); err == nil {
+		t.Fatalf("expected error for missing address")
+	}
+
+	// Values of other flags are never taken for --mailbox.
+	mailbox, rest, _ = splitMailboxFlag([]string{"send", "--subject", "--mailbox", "--body", "--mailbox", "--to", "a@contoso.com"})
+	if mailbox != "" || strings.Join(rest, " ") != "send --subject --mailbox --body --mailbox --to a@contoso.com" {
+		t.Fatalf("unexpected split %q %v", mailbox, rest)
+	}
+	mailbox, rest, _ = splitMailboxFlag([]string{"send", "--body-html", "--mailbox", "team@contoso.com", "--body-file", "-"})
+	if mailbox != "team@contoso.com" || strings.Join(rest, " ") != "send --body-html --body-file -" {
+		t.Fatalf("unexpected split %q %v", mailbox, rest)
+	}
+}
+
+func TestMailPathsUseMailbox(t *testing.T) {
+	id := identityContext{Mailbox: "support@contoso.com"}
+	if got := messagePath(id, "m1"); got != "/v1.0/users/support@contoso.com/messages/m1" {
+		t.Fatalf("unexpected message path %q", got)
+	}
+	if got := mailFolderPath(identityContext{}, "inbox"); got != "/v1.0/me/mailFolders/inbox" {
+		t.Fatalf("unexpected folder path %q", got)
+	}
+}
+
+func TestMailSendOnBehalfUsesOwnMailbox(t *testing.T) {
+	var gotPath string
+	var payload map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	id := identityContext{Mailbox: "support@contoso.com"}
+	code := runMailSend(rt, id, []string{"--to", "a@example.com", "--subject", "hi", "--body", "x", "--on-behalf"})
+	if code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	if gotPath != "/v1.0/me/sendMail" {
+		t.Fatalf("expected own sendMail, got %s", gotPath)
+	}
+	msg, _ := payload["message"].(map[string]any)
+	if emailAddressString(msg["from"]) != "support@contoso.com" {
+		t.Fatalf("expected from set to mailbox, got %v", msg["from"])
+	}
+	if !strings.Contains(out.String(), `"mode":"on-behalf"`) {
+		t.Fatalf("unexpected output %s", out.String())
+	}
+}
+
+func TestMailSendAsUsesSharedMailbox(t *testing.T) {
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	rt.cachedToken = secrets.AccessToken{
+		AccessToken: "test-token",
+		Scope:       "Mail.Send Mail.Send.Shared",
+		ExpiresAt:   time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	}
+	id := identityContext{Mailbox: "support@contoso.com"}
+	if code := runMailSend(rt, id, []string{"--to", "a@example.com", "--subject", "hi", "--body", "x"}); code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	if gotPath != "/v1.0/users/support@contoso.com/sendMail" {
+		t.Fatalf("expected shared sendMail, got %s", gotPath)
+	}
+}
+
+func TestMailSendAsLargeAttachmentNeedsSharedReadWrite(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+	var paths []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v1.0/users/support@contoso.com/messages":
+			_, _ = w.Write([]byte(`{"id":"d1"}`))
+		case strings.HasSuffix(r.URL.Path, "/createUploadSession"):
+			_, _ = w.Write([]byte(`{"uploadUrl":"` + srv.URL + `/upload"}`))
+		case r.URL.Path == "/upload":
+			var start, end, total int64
+			_, _ = fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+			if end+1 == total {
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"nextExpectedRanges":["%d-"]}`, end+1)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := f.Truncate(mailInlineAttachmentMax + 1); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	_ = f.Close()
+	lookup := fakeEnv(map[string]string{"MO_GRAPH_BASE_URL": srv.URL, "MO_KEYRING_BACKEND": "file", "MO_KEYRING_PASSWORD": "pw"})
+	store, _, err := secrets.OpenStore(lookup, config.AppConfig{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	rt.lookup = lookup
+	rt.cachedToken.Scope = "Mail.Send Mail.Send.Shared"
+	id := identityContext{Account: "me@contoso.com", Mailbox: "support@contoso.com", Store: store}
+	args := []string{"--to", "a@example.com", "--subject", "hi", "--body", "x", "--attach", path}
+	// Without the shared read/write scope the token cannot be used, and no
+	// draft is created in the shared mailbox.
+	if code := runMailSend(rt, id, args); code == 0 || len(paths) != 0 {
+		t.Fatalf("expected a missing scope to stop the send, got %d with requests %v", code, paths)
+	}
+
+	rt.cachedToken.Scope = "Mail.Send Mail.Send.Shared Mail.ReadWrite.Shared"
+	if code := runMailSend(rt, id, args); code != 0 {
+		t.Fatalf("expected success, got %d: %s", code, rt.stderr.(*bytes.Buffer).String())
+	}
+	if len(paths) == 0 || paths[0] != "POST /v1.0/users/support@contoso.com/messages" || paths[len(paths)-1] != "POST /v1.0/users/support@contoso.com/messages/d1/send" {
+		t.Fatalf("expected a draft sent from the shared mailbox, got %v", paths)
+	}
+}
+
+func TestOwnMailboxChangesNeedMailReadWrite(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"m2"}`))
+	}))
+	defer srv.Close()
+
+	for sub, want := range map[string]bool{"move": true, "draft": true, "folder": true, "list": false, "send": false} {
+		if got := len(ownMailScopes(sub)) > 0; got != want {
+			t.Fatalf("ownMailScopes(%q) requests Mail.ReadWrite = %v, want %v", sub, got, want)
+		}
+	}
+
+	lookup := fakeEnv(map[string]string{"MO_GRAPH_BASE_URL": srv.URL, "MO_KEYRING_BACKEND": "file", "MO_KEYRING_PASSWORD": "pw"})
+	store, _, err := secrets.OpenStore(lookup, config.AppConfig{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	rt.lookup = lookup
+	// An account authorized before Mail.ReadWrite was a default scope.
+	rt.cachedToken.Scope = "Mail.Read Mail.Send"
+	id := identityContext{Account: "me@contoso.com", Store: store, Scopes: ownMailScopes("move")}
+	if code := runMailMoveOrCopy(rt, id, "move", []string{"m1", "--folder", "archive"}); code == 0 || len(paths) != 0 {
+		t.Fatalf("expected the missing scope to stop the move, got %d with requests %v", code, paths)
+	}
+
+	rt.cachedToken.Scope = "Mail.ReadWrite Mail.Send"
+	if code := runMailMoveOrCopy(rt, id, "move", []string{"m1", "--folder", "archive"}); code != 0 {
+		t.Fatalf("move exit %d: %s", code, rt.stderr)
+	}
+}
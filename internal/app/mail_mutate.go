@@ -0,0 +1,262 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// newMessageID reports the id of the message created by move/copy; Graph
+// assigns a new id when a message changes folders.
+func newMessageID(body map[string]any) map[string]any {
+	if v := asString(body["id"]); v != "" {
+		return map[string]any{"new_id": v}
+	}
+	return nil
+}
+
+func runMailMoveOrCopy(rt *runtimeState, id identityContext, action string, args []string) int {
+	usage := fmt.Sprintf("Usage: mo mail %s <message-id>... --folder FOLDER", action)
+	fs := flag.NewFlagSet("mail "+action, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	folder := fs.String("folder", "", "Destination folder id, well-known name, or path such as Inbox/Projects")
+	rest, err := parseFlagsAndIDs(fs, args)
+	if err != nil {
+		return rt.failErr(usageError(fmt.Sprintf("invalid mail %s flags", action), usage))
+	}
+	ids, err := positionalIDs(rest, "message id", usage)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if strings.TrimSpace(*folder) == "" {
+		return rt.failErr(usageError("--folder is required", usage))
+	}
+	dest, err := rt.resolveMailFolder(id, *folder)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	verb := "moved"
+	if action == "copy" {
+		verb = "copied"
+	}
+	return rt.writeMutation(id, ids, verb, func(msgID string) batchRequest {
+		return batchRequest{Method: http.MethodPost, URL: messagePath(id, msgID) + "/" + action, Body: map[string]any{"destinationId": dest}}
+	}, newMessageID, map[string]any{"folder": dest})
+}
+
+func runMailDelete(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail delete <message-id>... [--permanent]"
+	fs := flag.NewFlagSet("mail delete", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	permanent := fs.Bool("permanent", false, "Permanently delete instead of moving to Deleted Items")
+	rest, err := parseFlagsAndIDs(fs, args)
+	if err != nil {
+		return rt.failErr(usageError("invalid mail delete flags", usage))
+	}
+	ids, err := positionalIDs(rest, "message id", usage)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	prompt := "Delete message?"
+	if *permanent {
+		prompt = "Permanently delete message?"
+	}
+	if len(ids) > 1 {
+		prompt = fmt.Sprintf("Delete %d messages?", len(ids))
+		if *permanent {
+			prompt = fmt.Sprintf("Permanently delete %d messages?", len(ids))
+		}
+	}
+	ok, err := confirmAction(rt, prompt)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if !ok {
+		if len(ids) > 1 {
+			return rt.writeJSON(map[string]any{"deleted": false, "ids": ids})
+		}
+		return rt.writeJSON(map[string]any{"deleted": false, "id": ids[0]})
+	}
+
+	return rt.writeMutation(id, ids, "deleted", func(msgID string) batchRequest {
+		if *permanent {
+			return batchRequest{Method: http.MethodPost, URL: messagePath(id, msgID) + "/permanentDelete"}
+		}
+		return batchRequest{Method: http.MethodDelete, URL: messagePath(id, msgID)}
+	}, nil, map[string]any{"permanent": *permanent})
+}
+
+func runMailMark(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail mark <message-id>... --read|--unread"
+	fs := flag.NewFlagSet("mail mark", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	read := fs.Bool("read", false, "Mark as read")
+	unread := fs.Bool("unread", false, "Mark as unread")
+	rest, err := parseFlagsAndIDs(fs, args)
+	if err != nil {
+		return rt.failErr(usageError("invalid mail mark flags", usage))
+	}
+	ids, err := positionalIDs(rest, "message id", usage)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if *read == *unread {
+		return rt.failErr(usageError("exactly one of --read or --unread is required", usage))
+	}
+
+	return rt.writeMutation(id, ids, "updated", func(msgID string) batchRequest {
+		return batchRequest{Method: http.MethodPatch, URL: messagePath(id, msgID), Body: map[string]any{"isRead": *read}}
+	}, nil, map[string]any{"is_read": *read})
+}
+
+func runMailFlag(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail flag <message-id>... --status flagged|complete|notFlagged [--due RFC3339]"
+	fs := flag.NewFlagSet("mail flag", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	status := fs.String("status", "flagged", "Flag status: flagged|complete|notFlagged")
+	due := fs.String("due", "", "Follow-up due time (RFC3339), with --status flagged")
+	rest, err := parseFlagsAndIDs(fs, args)
+	if err != nil {
+		return rt.failErr(usageError("invalid mail flag flags", usage))
+	}
+	ids, err := positionalIDs(rest, "message id", usage)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	flagStatus := ""
+	switch strings.ToLower(strings.TrimSpace(*status)) {
+	case "flagged":
+		flagStatus = "flagged"
+	case "complete", "completed":
+		flagStatus = "complete"
+	case "notflagged", "none", "clear":
+		flagStatus = "notFlagged"
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("invalid --status %q", *status), "Use flagged, complete or notFlagged."))
+	}
+	followup := map[string]any{"flagStatus": flagStatus}
+	now := time.Now().UTC()
+	if strings.TrimSpace(*due) != "" {
+		if flagStatus != "flagged" {
+			return rt.failErr(usageError("--due requires --status flagged", usage))
+		}
+		dueAt, err := time.Parse(time.RFC3339, strings.TrimSpace(*due))
+		if err != nil {
+			return rt.failErr(usageError("invalid --due timestamp", "Use RFC3339 format, e.g. 2026-02-20T17:00:00Z."))
+		}
+		// Graph requires a start time whenever a due time is set.
+		start := now
+		if dueAt.Before(start) {
+			start = dueAt
+		}
+		followup["startDateTime"] = graphDateTime(start)
+		followup["dueDateTime"] = graphDateTime(dueAt)
+	}
+	if flagStatus == "complete" {
+		followup["completedDateTime"] = graphDateTime(now)
+	}
+
+	return rt.writeMutation(id, ids, "updated", func(msgID string) batchRequest {
+		return batchRequest{Method: http.MethodPatch, URL: messagePath(id, msgID), Body: map[string]any{"flag": followup}}
+	}, nil, map[string]any{"flag_status": flagStatus})
+}
+
+func graphDateTime(t time.Time) map[string]any {
+	return map[string]any{"dateTime": t.UTC().Format(time.RFC3339), "timeZone": "UTC"}
+}
+
+func runMailCategorize(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail categorize <message-id>... [--add NAME]... [--remove NAME]... [--clear]"
+	fs := flag.NewFlagSet("mail categorize", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var add, remove stringList
+	fs.Var(&add, "add", "Category to add (repeatable, comma-separated)")
+	fs.Var(&remove, "remove", "Category to remove (repeatable, comma-separated)")
+	clearAll := fs.Bool("clear", false, "Remove all categories before adding")
+	rest, err := parseFlagsAndIDs(fs, args)
+	if err != nil {
+		return rt.failErr(usageError("invalid mail categorize flags", usage))
+	}
+	ids, err := positionalIDs(rest, "message id", usage)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	added, removed := splitCategories(add), splitCategories(remove)
+	if len(added) == 0 && len(removed) == 0 && !*clearAll {
+		return rt.failErr(usageError("nothing to change", usage))
+	}
+
+	// Graph replaces the whole categories list, so each message's current
+	// list is read first. Nothing is changed if any message cannot be read.
+	current := map[string][]string{}
+	if !*clearAll {
+		current, err = rt.messageCategories(id, ids)
+		if err != nil {
+			return rt.failErr(err)
+		}
+	}
+	return rt.writeMutation(id, ids, "updated", func(msgID string) batchRequest {
+		return batchRequest{Method: http.MethodPatch, URL: messagePath(id, msgID), Body: map[string]any{"categories": mergeCategories(current[msgID], added, removed)}}
+	}, func(body map[string]any) map[string]any {
+		if v, ok := body["categories"]; ok {
+			return map[string]any{"categories": v}
+		}
+		return nil
+	}, map[string]any{"added": added, "removed": removed})
+}
+
+// messageCategories returns the categories of each message, read in one
+// request or through /$batch.
+func (rt *runtimeState) messageCategories(id identityContext, ids []string) (map[string][]string, error) {
+	out := make(map[string][]string, len(ids))
+	if len(ids) == 1 {
+		q := url.Values{}
+		q.Set("$select", "categories")
+		var msg struct {
+			Categories []string `json:"categories"`
+		}
+		if _, err := rt.graphRequest(id, http.MethodGet, messagePath(id, ids[0]), q, nil, &msg); err != nil {
+			return nil, err
+		}
+		out[ids[0]] = msg.Categories
+		return out, nil
+	}
+	reqs := make([]batchRequest, 0, len(ids))
+	for i, msgID := range ids {
+		reqs = append(reqs, batchRequest{ID: strconv.Itoa(i + 1), Method: http.MethodGet, URL: messagePath(id, msgID) + "?$select=categories"})
+	}
+	got, err := rt.graphBatch(context.Background(), id, reqs)
+	if err != nil {
+		return nil, err
+	}
+	for i, msgID := range ids {
+		r, ok := got[reqs[i].ID]
+		if !ok {
+			return nil, transientError(fmt.Sprintf("no response reading message %s", msgID), "Retry the command.")
+		}
+		if err := r.err(); err != nil {
+			if ae, ok := err.(*appError); ok {
+				named := *ae
+				named.Message = fmt.Sprintf("message %s: %s", msgID, ae.Message)
+				return nil, &named
+			}
+			return nil, err
+		}
+		var msg struct {
+			Categories []string `json:"categories"`
+		}
+		_ = json.Unmarshal(r.Body, &msg)
+		out[msgID] = msg.Categories
+	}
+	return out, nil
+}
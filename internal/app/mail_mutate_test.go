@@ -0,0 +1,49 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMailMoveReportsNewMessageID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1.0/me/messages/m1/move" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["destinationId"] != "archive" {
+			t.Errorf("unexpected body %v", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"m1-new"}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runMailMoveOrCopy(rt, identityContext{}, "move", []string{"m1", "--folder", "archive"}); code != 0 {
+		t.Fatalf("expected success, got %d: %s", code, out.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid output: %v", err)
+	}
+	if got["moved"] != true || got["id"] != "m1" || got["new_id"] != "m1-new" || got["folder"] != "archive" {
+		t.Fatalf("unexpected output %v", got)
+	}
+}
+
+func TestMailMarkRequiresExactlyOneState(t *testing.T) {
+	var out bytes.Buffer
+	rt := &runtimeState{stdout: &out, stderr: &bytes.Buffer{}, lookup: fakeEnv(nil)}
+	if code := runMailMark(rt, identityContext{}, []string{"m1", "--read", "--unread"}); code == 0 {
+		t.Fatalf("expected usage error when both --read and --unread are set")
+	}
+	if code := runMailMark(rt, identityContext{}, []string{"m1"}); code == 0 {
+		t.Fatalf("expected usage error when neither --read nor --unread is set")
+	}
+}
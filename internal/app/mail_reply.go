@@ -0,0 +1,144 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mailRespondAction maps a mo subcommand to the Graph message actions that
+// send a response directly or create a draft of it.
+type mailRespondAction struct {
+	Name   string
+	Send   string
+	Create string
+}
+
+var mailRespondActions = map[string]mailRespondAction{
+	"reply":     {Name: "reply", Send: "reply", Create: "createReply"},
+	"reply-all": {Name: "reply-all", Send: "replyAll", Create: "createReplyAll"},
+	"forward":   {Name: "forward", Send: "forward", Create: "createForward"},
+}
+
+func runMailRespond(rt *runtimeState, id identityContext, action mailRespondAction, args []string) int {
+	usage := fmt.Sprintf("Usage: mo mail %s <message-id> [--comment TEXT] [--body-html] [--attach PATH]...", action.Name)
+	if action.Name == "forward" {
+		usage = "Usage: mo mail forward <message-id> --to <emails> [--cc ...] [--bcc ...] [--comment TEXT] [--body-html] [--attach PATH]..."
+	}
+
+	fs := flag.NewFlagSet("mail "+action.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	comment := fs.String("comment", "", "Text added above the quoted message")
+	htmlBody := fs.Bool("body-html", false, "Treat --comment as HTML")
+	var attach stringList
+	fs.Var(&attach, "attach", "File to attach (repeatable)")
+	var to, cc, bcc *string
+	if action.Name == "forward" {
+		to = fs.String("to", "", "Comma-separated recipients")
+		cc = fs.String("cc", "", "Comma-separated cc recipients")
+		bcc = fs.String("bcc", "", "Comma-separated bcc recipients")
+	}
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError(fmt.Sprintf("invalid mail %s flags", action.Name), usage))
+	}
+	if fs.NArg() != 1 || strings.TrimSpace(fs.Arg(0)) == "" {
+		return rt.failErr(usageError("message id is required", usage))
+	}
+	msgID := strings.TrimSpace(fs.Arg(0))
+	if to != nil && strings.TrimSpace(*to) == "" {
+		return rt.failErr(usageError("--to is required", usage))
+	}
+	atts, err := loadMailAttachments(attach)
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	payload := map[string]any{}
+	if to != nil {
+		payload["message"] = map[string]any{
+			"toRecipients":  emailRecipients(*to),
+			"ccRecipients":  emailRecipients(*cc),
+			"bccRecipients": emailRecipients(*bcc),
+		}
+	}
+	msgPath := messagePath(id, msgID)
+
+	switch {
+	case len(atts) == 0 && !*htmlBody:
+		payload["comment"] = *comment
+		_, err = rt.graphRequest(id, http.MethodPost, msgPath+"/"+action.Send, nil, payload, nil)
+	default:
+		// Attachments and HTML comments need a draft: Graph's comment field
+		// is plain text and the direct actions take no attachments.
+		if !*htmlBody {
+			payload["comment"] = *comment
+		}
+		if id.Mailbox == "" {
+			id.Scopes = append(id.Scopes, "Mail.ReadWrite")
+		}
+		var draft struct {
+			ID string `json:"id"`
+		}
+		if _, err := rt.graphRequest(id, http.MethodPost, msgPath+"/"+action.Create, nil, payload, &draft); err != nil {
+			return rt.failErr(err)
+		}
+		if *htmlBody && strings.TrimSpace(*comment) != "" {
+			if err := rt.prependDraftHTML(id, draft.ID, *comment); err != nil {
+				_, _ = rt.graphRequest(id, http.MethodDelete, messagePath(id, draft.ID), nil, nil, nil)
+				return rt.failErr(err)
+			}
+		}
+		err = rt.attachAndSendDraft(id, draft.ID, atts)
+	}
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	out := map[string]any{"status": "sent", "action": action.Name, "message_id": msgID}
+	if len(atts) > 0 {
+		out["attachments"] = len(atts)
+	}
+	return rt.writeJSON(out)
+}
+
+// prependDraftHTML inserts comment at the top of a draft's body, above the
+// quoted original that createReply/createForward generated.
+func (rt *runtimeState) prependDraftHTML(id identityContext, draftID, comment string) error {
+	draftPath := messagePath(id, draftID)
+	q := url.Values{}
+	q.Set("$select", "body")
+	var draft struct {
+		Body struct {
+			ContentType string `json:"contentType"`
+			Content     string `json:"content"`
+		} `json:"body"`
+	}
+	if _, err := rt.graphRequest(id, http.MethodGet, draftPath, q, nil, &draft); err != nil {
+		return err
+	}
+	content := draft.Body.Content
+	if !strings.EqualFold(draft.Body.ContentType, "html") {
+		content = "<pre>" + html.EscapeString(content) + "</pre>"
+	}
+	_, err := rt.graphRequest(id, http.MethodPatch, draftPath, nil, map[string]any{
+		"body": mailBody(insertHTMLComment(content, comment), true),
+	}, nil)
+	return err
+}
+
+// insertHTMLComment places comment right after the opening <body> tag, or at
+// the start when the document has none.
+func insertHTMLComment(doc, comment string) string {
+	lower := strings.ToLower(doc)
+	if i := strings.Index(lower, "<body"); i >= 0 {
+		if j := strings.Index(lower[i:], ">"); j >= 0 {
+			at := i + j + 1
+			return doc[:at] + comment + doc[at:]
+		}
+	}
+	return comment + doc
+}
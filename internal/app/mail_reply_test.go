@@ -0,0 +1,65 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInsertHTMLCommentAfterBodyTag(t *testing.T) {
+	got := insertHTMLComment(`<html><BODY class="x"><p>quoted</p></BODY></html>`, "<p>hi</p>")
+	want := `<html><BODY class="x"><p>hi</p><p>quoted</p></BODY></html>`
+	if got != want {
+		t.Fatalf("insertHTMLComment = %q, want %q", got, want)
+	}
+	if got := insertHTMLComment("<p>quoted</p>", "<p>hi</p>"); got != "<p>hi</p><p>quoted</p>" {
+		t.Fatalf("expected comment prepended without body tag, got %q", got)
+	}
+}
+
+func TestMailForwardHTMLUsesDraft(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/createForward"):
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if _, ok := body["comment"]; ok {
+				t.Errorf("HTML comment must not be sent as plain comment")
+			}
+			_, _ = w.Write([]byte(`{"id":"draft-1"}`))
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"body":{"contentType":"html","content":"<html><body><p>orig</p></body></html>"}}`))
+		case r.Method == http.MethodPatch:
+			var body map[string]map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if !strings.Contains(body["body"]["content"], "<body><b>FYI</b><p>orig</p>") {
+				t.Errorf("unexpected patched body %q", body["body"]["content"])
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	code := runMailRespond(rt, identityContext{}, mailRespondActions["forward"], []string{"msg-1", "--to", "a@example.com", "--comment", "<b>FYI</b>", "--body-html"})
+	if code != 0 {
+		t.Fatalf("runMailRespond exit %d: %s", code, rt.stderr)
+	}
+	want := []string{
+		"POST /v1.0/me/messages/msg-1/createForward",
+		"GET /v1.0/me/messages/draft-1",
+		"PATCH /v1.0/me/messages/draft-1",
+		"POST /v1.0/me/messages/draft-1/send",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected calls:\n%s", strings.Join(calls, "\n"))
+	}
+}
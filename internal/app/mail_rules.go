@@ -0,0 +1,395 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+	"gopkg.in/yaml.v3"
+)
+
+func mailRulesPath(id identityContext) string {
+	return mailRoot(id) + "/mailFolders/inbox/messageRules"
+}
+
+// mailRuleReadOnly lists messageRule properties Graph sets itself; they are
+// ignored in rule files and when comparing rules.
+var mailRuleReadOnly = []string{"id", "hasError", "isReadOnly", "@odata.context", "@odata.etag"}
+
+func runMailRules(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
+		return exitcode.Success
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	rest := args[1:]
+	switch sub {
+	case "list":
+		if len(rest) != 0 {
+			return rt.failErr(usageError("mail rules list does not take arguments", "Usage: mo mail rules list"))
+		}
+		return rt.writeList(id, mailRulesPath(id), nil, listOptions{All: true}, func(it map[string]any) string {
+			return fmt.Sprintf("%s\t%d\t%v\t%s", asString(it["id"]), asInt64(it["sequence"]), it["isEnabled"], strings.ReplaceAll(asString(it["displayName"]), "\t", " "))
+		}, nil)
+	case "get":
+		if len(rest) != 1 || strings.TrimSpace(rest[0]) == "" {
+			return rt.failErr(usageError("rule id is required", "Usage: mo mail rules get <rule-id>"))
+		}
+		var out map[string]any
+		if _, err := rt.graphRequest(id, http.MethodGet, mailRulePath(id, rest[0]), nil, nil, &out); err != nil {
+			return rt.failErr(err)
+		}
+		delete(out, "@odata.context")
+		return rt.writeJSON(out)
+	case "create":
+		if len(rest) != 1 {
+			return rt.failErr(usageError("rule file is required", "Usage: mo mail rules create <rule.json|rule.yaml|->"))
+		}
+		rule, err := loadMailRule(rest[0])
+		if err != nil {
+			return rt.failErr(err)
+		}
+		if err := rt.resolveRuleFolders(id, rule); err != nil {
+			return rt.failErr(err)
+		}
+		var out map[string]any
+		if _, err := rt.graphRequest(id, http.MethodPost, mailRulesPath(id), nil, rule, &out); err != nil {
+			return rt.failErr(err)
+		}
+		delete(out, "@odata.context")
+		return rt.writeJSON(out)
+	case "update":
+		if len(rest) != 2 || strings.TrimSpace(rest[0]) == "" {
+			return rt.failErr(usageError("rule id and rule file are required", "Usage: mo mail rules update <rule-id> <rule.json|rule.yaml|->"))
+		}
+		rule, err := loadMailRule(rest[1])
+		if err != nil {
+			return rt.failErr(err)
+		}
+		if err := rt.resolveRuleFolders(id, rule); err != nil {
+			return rt.failErr(err)
+		}
+		var out map[string]any
+		if _, err := rt.graphRequest(id, http.MethodPatch, mailRulePath(id, rest[0]), nil, rule, &out); err != nil {
+			return rt.failErr(err)
+		}
+		delete(out, "@odata.context")
+		return rt.writeJSON(out)
+	case "delete":
+		if len(rest) != 1 || strings.TrimSpace(rest[0]) == "" {
+			return rt.failErr(usageError("rule id is required", "Usage: mo mail rules delete <rule-id>"))
+		}
+		ruleID := strings.TrimSpace(rest[0])
+		ok, err := confirmAction(rt, "Delete inbox rule?")
+		if err != nil {
+			return rt.failErr(err)
+		}
+		if !ok {
+			return rt.writeJSON(map[string]any{"deleted": false, "id": ruleID})
+		}
+		if _, err := rt.graphRequest(id, http.MethodDelete, mailRulePath(id, ruleID), nil, nil, nil); err != nil {
+			return rt.failErr(err)
+		}
+		return rt.writeJSON(map[string]any{"deleted": true, "id": ruleID})
+	case "apply":
+		return runMailRulesApply(rt, id, rest)
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown mail rules subcommand %q", sub), "Run 'mo mail help' for usage."))
+	}
+}
+
+func mailRulePath(id identityContext, ruleID string) string {
+	return mailRulesPath(id) + "/" + url.PathEscape(strings.TrimSpace(ruleID))
+}
+
+// readRuleDocument parses a JSON or YAML file (or stdin for "-"). YAML is a
+// superset of JSON, so one decoder handles both; the result is normalized
+// through JSON so numbers compare the same way as Graph responses.
+func readRuleDocument(path string) (any, error) {
+	data, err := readInput("rule file", path, 0)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, usageError(fmt.Sprintf("%s is not valid JSON or YAML", path), err.Error())
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, usageError(fmt.Sprintf("%s cannot be converted to JSON", path), err.Error())
+	}
+	var out any
+	_ = json.Unmarshal(raw, &out)
+	return out, nil
+}
+
+func loadMailRule(path string) (map[string]any, error) {
+	doc, err := readRuleDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	rule, ok := doc.(map[string]any)
+	if !ok {
+		return nil, usageError("rule file must contain a single rule object", "Use 'mo mail rules apply' for a list of rules.")
+	}
+	for _, k := range mailRuleReadOnly {
+		delete(rule, k)
+	}
+	return rule, nil
+}
+
+// loadMailRuleSet reads the rules for apply: either a list of rules or an
+// object with a "rules" list. Rules are matched by displayName, which must
+// be unique; missing sequence numbers follow the file order.
+func loadMailRuleSet(path string) ([]map[string]any, error) {
+	doc, err := readRuleDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := doc.(map[string]any); ok {
+		doc = m["rules"]
+	}
+	list, ok := doc.([]any)
+	if !ok {
+		return nil, usageError("rule file must contain a list of rules", "Use a top-level list or a \"rules\" key.")
+	}
+	seen := map[string]bool{}
+	rules := make([]map[string]any, 0, len(list))
+	for i, v := range list {
+		rule, ok := v.(map[string]any)
+		if !ok {
+			return nil, usageError(fmt.Sprintf("rule %d is not an object", i+1), "Each rule needs displayName, conditions and actions.")
+		}
+		for _, k := range mailRuleReadOnly {
+			delete(rule, k)
+		}
+		name := strings.TrimSpace(asString(rule["displayName"]))
+		if name == "" {
+			return nil, usageError(fmt.Sprintf("rule %d has no displayName", i+1), "apply matches rules by displayName.")
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, usageError(fmt.Sprintf("duplicate rule %q", name), "displayName must be unique in the rule file.")
+		}
+		seen[strings.ToLower(name)] = true
+		if _, ok := rule["sequence"]; !ok {
+			rule["sequence"] = float64(i + 1)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// resolveRuleFolders replaces folder names and paths in moveToFolder and
+// copyToFolder actions with folder ids, which is all Graph accepts there.
+func (rt *runtimeState) resolveRuleFolders(id identityContext, rule map[string]any) error {
+	actions, ok := rule["actions"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	for _, key := range []string{"moveToFolder", "copyToFolder"} {
+		ref := strings.TrimSpace(asString(actions[key]))
+		if ref == "" {
+			continue
+		}
+		folderID, err := rt.resolveMailFolder(id, ref)
+		if err != nil {
+			return err
+		}
+		if !looksLikeGraphID(folderID) {
+			// A well-known name; look up the id it stands for.
+			q := url.Values{}
+			q.Set("$select", "id")
+			var out struct {
+				ID string `json:"id"`
+			}
+			if _, err := rt.graphRequest(id, http.MethodGet, mailFolderPath(id, folderID), q, nil, &out); err != nil {
+				return err
+			}
+			folderID = out.ID
+		}
+		actions[key] = folderID
+	}
+	return nil
+}
+
+type mailRuleChange struct {
+	Action string         `json:"action"`
+	Name   string         `json:"name"`
+	ID     string         `json:"id,omitempty"`
+	Fields []string       `json:"fields,omitempty"`
+	Rule   map[string]any `json:"-"`
+	Error  map[string]any `json:"error,omitempty"`
+}
+
+// planMailRules compares the desired rules with the mailbox. A rule is
+// unchanged when every property in the file matches; properties the file
+// leaves out are not compared.
+func planMailRules(desired, existing []map[string]any, prune bool) []mailRuleChange {
+	byName := map[string]map[string]any{}
+	for _, r := range existing {
+		byName[strings.ToLower(strings.TrimSpace(asString(r["displayName"])))] = r
+	}
+	plan := make([]mailRuleChange, 0, len(desired))
+	for _, want := range desired {
+		name := strings.TrimSpace(asString(want["displayName"]))
+		have, ok := byName[strings.ToLower(name)]
+		if !ok {
+			plan = append(plan, mailRuleChange{Action: "create", Name: name, Rule: want})
+			continue
+		}
+		delete(byName, strings.ToLower(name))
+		fields := make([]string, 0)
+		for k, v := range want {
+			if !ruleValueMatches(v, have[k]) {
+				fields = append(fields, k)
+			}
+		}
+		sort.Strings(fields)
+		action := "unchanged"
+		if len(fields) > 0 {
+			action = "update"
+		}
+		plan = append(plan, mailRuleChange{Action: action, Name: name, ID: asString(have["id"]), Fields: fields, Rule: want})
+	}
+	if prune {
+		extra := make([]mailRuleChange, 0, len(byName))
+		for _, r := range byName {
+			extra = append(extra, mailRuleChange{Action: "delete", Name: asString(r["displayName"]), ID: asString(r["id"])})
+		}
+		sort.Slice(extra, func(i, j int) bool { return extra[i].Name < extra[j].Name })
+		plan = append(plan, extra...)
+	}
+	return plan
+}
+
+// ruleValueMatches reports whether want is contained in have: objects match
+// on the keys want sets, everything else must be equal.
+func ruleValueMatches(want, have any) bool {
+	wm, ok := want.(map[string]any)
+	if !ok {
+		if wl, ok := want.([]any); ok {
+			hl, ok := have.([]any)
+			if !ok || len(wl) != len(hl) {
+				return len(wl) == 0 && have == nil
+			}
+			for i := range wl {
+				if !ruleValueMatches(wl[i], hl[i]) {
+					return false
+				}
+			}
+			return true
+		}
+		return reflect.DeepEqual(want, have)
+	}
+	hm, _ := have.(map[string]any)
+	for k, v := range wm {
+		if !ruleValueMatches(v, hm[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func runMailRulesApply(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail rules apply <rules.json|rules.yaml|-> [--prune] [--dry-run]"
+	fs := flag.NewFlagSet("mail rules apply", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	prune := fs.Bool("prune", false, "Delete inbox rules that are not in the file")
+	dryRun := fs.Bool("dry-run", false, "Only report the changes")
+	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
+		return rt.failErr(usageError("invalid mail rules apply flags", usage))
+	}
+	if fs.NArg() != 1 {
+		return rt.failErr(usageError("rule file is required", usage))
+	}
+	desired, err := loadMailRuleSet(fs.Arg(0))
+	if err != nil {
+		return rt.failErr(err)
+	}
+	for _, rule := range desired {
+		if err := rt.resolveRuleFolders(id, rule); err != nil {
+			return rt.failErr(err)
+		}
+	}
+
+	existing := make([]map[string]any, 0)
+	if _, err := rt.listPages(id, mailRulesPath(id), nil, listOptions{All: true}, func(items []map[string]any) error {
+		existing = append(existing, items...)
+		return nil
+	}); err != nil {
+		return rt.failErr(err)
+	}
+	plan := planMailRules(desired, existing, *prune)
+	counts := map[string]int{"create": 0, "update": 0, "delete": 0, "unchanged": 0}
+	for _, c := range plan {
+		counts[c.Action]++
+	}
+	pending := counts["create"] + counts["update"] + counts["delete"]
+
+	if *dryRun || pending == 0 {
+		return rt.writeJSON(map[string]any{"applied": false, "dry_run": *dryRun, "changes": plan, "summary": counts})
+	}
+
+	// The diff goes to stderr so it is visible before the prompt while
+	// stdout stays a single JSON document.
+	for _, c := range plan {
+		switch c.Action {
+		case "create":
+			_, _ = fmt.Fprintf(rt.stderr, "+ create %s\n", c.Name)
+		case "update":
+			_, _ = fmt.Fprintf(rt.stderr, "~ update %s (%s)\n", c.Name, strings.Join(c.Fields, ", "))
+		case "delete":
+			_, _ = fmt.Fprintf(rt.stderr, "- delete %s\n", c.Name)
+		}
+	}
+	ok, err := confirmAction(rt, fmt.Sprintf("Apply %d rule changes?", pending))
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if !ok {
+		return rt.writeJSON(map[string]any{"applied": false, "dry_run": false, "changes": plan, "summary": counts})
+	}
+
+	failed := 0
+	exit := exitcode.Success
+	for i := range plan {
+		c := &plan[i]
+		var err error
+		switch c.Action {
+		case "create":
+			var out map[string]any
+			_, err = rt.graphRequest(id, http.MethodPost, mailRulesPath(id), nil, c.Rule, &out)
+			c.ID = asString(out["id"])
+		case "update":
+			_, err = rt.graphRequest(id, http.MethodPatch, mailRulePath(id, c.ID), nil, c.Rule, nil)
+		case "delete":
+			_, err = rt.graphRequest(id, http.MethodDelete, mailRulePath(id, c.ID), nil, nil, nil)
+		default:
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		failed++
+		c.Error = map[string]any{"message": err.Error()}
+		if ae, ok := err.(*appError); ok {
+			c.Error = map[string]any{"code": ae.Code, "message": ae.Message}
+			if exit == exitcode.Success {
+				exit = ae.Exit
+			}
+		} else if exit == exitcode.Success {
+			exit = exitcode.TransientError
+		}
+	}
+	if code := rt.writeJSON(map[string]any{"applied": true, "dry_run": false, "changes": plan, "summary": counts, "failed": failed}); code != exitcode.Success {
+		return code
+	}
+	return exit
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMailRuleSetAcceptsYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	doc := `rules:
+  - displayName: Newsletters
+    isEnabled: true
+    conditions:
+      senderContains: [news@]
+    actions:
+      moveToFolder: archive
+      stopProcessingRules: true
+  - displayName: Boss
+    sequence: 10
+    actions:
+      markImportance: high
+`
+	if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	rules, err := loadMailRuleSet(path)
+	if err != nil {
+		t.Fatalf("loadMailRuleSet: %v", err)
+	}
+	if len(rules) != 2 || rules[0]["sequence"] != float64(1) || rules[1]["sequence"] != float64(10) {
+		t.Fatalf("unexpected rules %v", rules)
+	}
+	cond := rules[0]["conditions"].(map[string]any)
+	if list, ok := cond["senderContains"].([]any); !ok || list[0] != "news@" {
+		t.Fatalf("expected YAML list to decode as JSON array, got %v", cond)
+	}
+}
+
+func TestPlanMailRules(t *testing.T) {
+	desired := []map[string]any{
+		{"displayName": "Keep", "sequence": float64(1), "actions": map[string]any{"markAsRead": true}},
+		{"displayName": "Change", "sequence": float64(2), "actions": map[string]any{"moveToFolder": "archive"}},
+		{"displayName": "New", "sequence": float64(3)},
+	}
+	existing := []map[string]any{
+		{"id": "r1", "displayName": "Keep", "sequence": float64(1), "isEnabled": true, "actions": map[string]any{"markAsRead": true, "stopProcessingRules": false}},
+		{"id": "r2", "displayName": "Change", "sequence": float64(2), "actions": map[string]any{"moveToFolder": "inbox"}},
+		{"id": "r3", "displayName": "Old", "sequence": float64(4)},
+	}
+
+	plan := planMailRules(desired, existing, true)
+	got := map[string]string{}
+	for _, c := range plan {
+		got[c.Name] = c.Action
+	}
+	want := map[string]string{"Keep": "unchanged", "Change": "update", "New": "create", "Old": "delete"}
+	for name, action := range want {
+		if got[name] != action {
+			t.Fatalf("expected %s to %s, got plan %+v", name, action, plan)
+		}
+	}
+	if plan[1].ID != "r2" || len(plan[1].Fields) != 1 || plan[1].Fields[0] != "actions" {
+		t.Fatalf("unexpected update entry %+v", plan[1])
+	}
+
+	if len(planMailRules(desired, existing, false)) != 3 {
+		t.Fatalf("expected no delete without prune")
+	}
+}
+
+func TestMailRulesApplyDryRunDoesNotWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`[{"displayName":"New","actions":{"markAsRead":true}}]`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	writes := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet {
+			writes++
+		}
+		_, _ = w.Write([]byte(`{"value":[{"id":"r9","displayName":"Old","sequence":1}]}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runMailRulesApply(rt, identityContext{}, []string{path, "--prune", "--dry-run"}); code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	if writes != 0 {
+		t.Fatalf("dry run sent %d writes", writes)
+	}
+	for _, want := range []string{`"action":"create","name":"New"`, `"action":"delete","name":"Old","id":"r9"`, `"applied":false`} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %s in %s", want, out.String())
+		}
+	}
+}
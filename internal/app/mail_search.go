@@ -0,0 +1,145 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const mailListSelect = "id,subject,from,receivedDateTime,isRead,bodyPreview,hasAttachments,importance,categories"
+
+// mailFilterFlags are the structured filters shared by mail list and mail
+// search. They become $filter clauses, or KQL terms when combined with
+// $search, because Graph does not accept $filter together with $search on
+// messages.
+type mailFilterFlags struct {
+	from, to, fromAddress, importance, category *string
+	unread, hasAttachments                      *bool
+	set                                         map[string]bool
+}
+
+func addMailFilterFlags(fs *flag.FlagSet) *mailFilterFlags {
+	f := &mailFilterFlags{}
+	f.from = fs.String("from", "", "Filter from received time (RFC3339)")
+	f.to = fs.String("to", "", "Filter to received time (RFC3339)")
+	f.fromAddress = fs.String("from-address", "", "Only messages from this sender address")
+	f.unread = fs.Bool("unread", false, "Only unread messages (--unread=false for read ones)")
+	f.hasAttachments = fs.Bool("has-attachments", false, "Only messages with attachments (--has-attachments=false for none)")
+	f.importance = fs.String("importance", "", "Only messages with this importance: low|normal|high")
+	f.category = fs.String("category", "", "Only messages in this category")
+	return f
+}
+
+// parsed records which filter flags were given; call it after fs.Parse.
+func (f *mailFilterFlags) parsed(fs *flag.FlagSet) error {
+	f.set = map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) { f.set[fl.Name] = true })
+	if strings.TrimSpace(*f.from) != "" {
+		if _, err := time.Parse(time.RFC3339, strings.TrimSpace(*f.from)); err != nil {
+			return usageError("invalid --from timestamp", "Use RFC3339 format, e.g. 2026-02-18T00:00:00Z.")
+		}
+	}
+	if strings.TrimSpace(*f.to) != "" {
+		if _, err := time.Parse(time.RFC3339, strings.TrimSpace(*f.to)); err != nil {
+			return usageError("invalid --to timestamp", "Use RFC3339 format, e.g. 2026-02-19T00:00:00Z.")
+		}
+	}
+	if f.set["importance"] {
+		v := strings.ToLower(strings.TrimSpace(*f.importance))
+		if v != "low" && v != "normal" && v != "high" {
+			return usageError(fmt.Sprintf("invalid --importance %q", *f.importance), "Use low, normal or high.")
+		}
+		*f.importance = v
+	}
+	return nil
+}
+
+// filter builds the $filter expression. Graph rejects message queries whose
+// $orderby property is not also the first $filter property, so a catch-all
+// receivedDateTime clause leads when no time range was given.
+func (f *mailFilterFlags) filter() string {
+	clauses := make([]string, 0, 6)
+	if v := strings.TrimSpace(*f.from); v != "" {
+		clauses = append(clauses, "receivedDateTime ge "+v)
+	}
+	if v := strings.TrimSpace(*f.to); v != "" {
+		clauses = append(clauses, "receivedDateTime le "+v)
+	}
+	timeClauses := len(clauses)
+	if v := strings.TrimSpace(*f.fromAddress); v != "" {
+		clauses = append(clauses, "from/emailAddress/address eq "+odataString(v))
+	}
+	if f.set["unread"] {
+		clauses = append(clauses, fmt.Sprintf("isRead eq %t", !*f.unread))
+	}
+	if f.set["has-attachments"] {
+		clauses = append(clauses, fmt.Sprintf("hasAttachments eq %t", *f.hasAttachments))
+	}
+	if f.set["importance"] {
+		clauses = append(clauses, "importance eq "+odataString(*f.importance))
+	}
+	if v := strings.TrimSpace(*f.category); v != "" {
+		clauses = append(clauses, "categories/any(c:c eq "+odataString(v)+")")
+	}
+	if timeClauses == 0 && len(clauses) > 0 {
+		clauses = append([]string{"receivedDateTime ge 1900-01-01T00:00:00Z"}, clauses...)
+	}
+	return strings.Join(clauses, " and ")
+}
+
+// search combines the user's KQL with the filter flags as KQL terms and
+// quotes the result for $search. Time ranges are matched by date.
+func (f *mailFilterFlags) search(kql string) string {
+	terms := make([]string, 0, 8)
+	if kql = strings.TrimSpace(kql); kql != "" {
+		terms = append(terms, "("+kql+")")
+	}
+	if v := strings.TrimSpace(*f.from); v != "" {
+		t, _ := time.Parse(time.RFC3339, v)
+		terms = append(terms, "received>="+t.UTC().Format("2006-01-02"))
+	}
+	if v := strings.TrimSpace(*f.to); v != "" {
+		t, _ := time.Parse(time.RFC3339, v)
+		terms = append(terms, "received<="+t.UTC().Format("2006-01-02"))
+	}
+	if v := strings.TrimSpace(*f.fromAddress); v != "" {
+		terms = append(terms, "from:"+kqlValue(v))
+	}
+	if f.set["unread"] {
+		terms = append(terms, fmt.Sprintf("isread:%t", !*f.unread))
+	}
+	if f.set["has-attachments"] {
+		terms = append(terms, fmt.Sprintf("hasattachments:%t", *f.hasAttachments))
+	}
+	if f.set["importance"] {
+		terms = append(terms, "importance:"+*f.importance)
+	}
+	if v := strings.TrimSpace(*f.category); v != "" {
+		terms = append(terms, "category:"+kqlValue(v))
+	}
+	return `"` + strings.ReplaceAll(strings.Join(terms, " AND "), `"`, `\"`) + `"`
+}
+
+func odataString(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+func kqlValue(v string) string {
+	if strings.ContainsAny(v, " \t\"():") {
+		return `"` + strings.ReplaceAll(v, `"`, "") + `"`
+	}
+	return v
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func parseMailFilters(t *testing.T, args ...string) *mailFilterFlags {
+	t.Helper()
+	fs := flag.NewFlagSet("t", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f := addMailFilterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := f.parsed(fs); err != nil {
+		t.Fatalf("parsed: %v", err)
+	}
+	return f
+}
+
+func TestMailFilterLeadsWithReceivedDateTime(t *testing.T) {
+	f := parseMailFilters(t, "--unread", "--from-address", "o'brien@example.com", "--importance", "HIGH")
+	want := "receivedDateTime ge 1900-01-01T00:00:00Z and from/emailAddress/address eq 'o''brien@example.com' and isRead eq false and importance eq 'high'"
+	if got := f.filter(); got != want {
+		t.Fatalf("unexpected filter:\n got %s\nwant %s", got, want)
+	}
+
+	f = parseMailFilters(t, "--from", "2026-02-18T00:00:00Z", "--has-attachments=false")
+	want = "receivedDateTime ge 2026-02-18T00:00:00Z and hasAttachments eq false"
+	if got := f.filter(); got != want {
+		t.Fatalf("unexpected filter:\n got %s\nwant %s", got, want)
+	}
+
+	if got := parseMailFilters(t).filter(); got != "" {
+		t.Fatalf("expected no filter without flags, got %q", got)
+	}
+}
+
+func TestMailSearchFoldsFiltersIntoKQL(t *testing.T) {
+	f := parseMailFilters(t, "--unread", "--category", "Red team", "--from", "2026-02-18T10:00:00Z")
+	want := `"(subject:report) AND received>=2026-02-18 AND isread:false AND category:\"Red team\""`
+	if got := f.search("subject:report"); got != want {
+		t.Fatalf("unexpected search:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestMailFilterRejectsBadImportance(t *testing.T) {
+	fs := flag.NewFlagSet("t", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f := addMailFilterFlags(fs)
+	_ = fs.Parse([]string{"--importance", "urgent"})
+	if err := f.parsed(fs); err == nil {
+		t.Fatalf("expected error for invalid importance")
+	}
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMailSendMIMEPostsBase64TextBody(t *testing.T) {
+	raw := "From: a@example.com\r\nTo: b@example.com\r\nSubject: hi\r\nX-Report-Id: 42\r\n\r\nhello\r\n"
+	path := filepath.Join(t.TempDir(), "msg.eml")
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.0/me/sendMail" || r.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		decoded, err := base64.StdEncoding.DecodeString(string(body))
+		if err != nil || string(decoded) != raw {
+			t.Errorf("expected base64 of the raw message, got %q (%v)", body, err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runMailSend(rt, identityContext{}, []string{"--mime", path}); code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	if code := runMailSend(rt, identityContext{}, []string{"--mime", path, "--to", "c@example.com"}); code == 0 {
+		t.Fatalf("expected usage error when combining --mime with --to")
+	}
+}
+
+func TestLoadMIMEMessageRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.eml")
+	if err := os.WriteFile(path, []byte("not a message"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := loadMIMEMessage(path); err == nil {
+		t.Fatalf("expected error for input without headers")
+	}
+}
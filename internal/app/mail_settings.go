@@ -0,0 +1,172 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+func mailboxSettingsPath(id identityContext) string {
+	return mailRoot(id) + "/mailboxSettings"
+}
+
+func runMailSettings(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
+		return exitcode.Success
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	switch sub {
+	case "get":
+		if len(args) != 1 {
+			return rt.failErr(usageError("mail settings get does not take arguments", "Usage: mo mail settings get"))
+		}
+		q := url.Values{}
+		q.Set("$select", "timeZone,workingHours,language,dateFormat,timeFormat,automaticRepliesSetting")
+		var out map[string]any
+		if _, err := rt.graphRequest(id, http.MethodGet, mailboxSettingsPath(id), q, nil, &out); err != nil {
+			return rt.failErr(err)
+		}
+		delete(out, "@odata.context")
+		return rt.writeJSON(out)
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown mail settings subcommand %q", sub), "Usage: mo mail settings get"))
+	}
+}
+
+// mailboxTimeZone returns the time zone configured in the mailbox, as Graph
+// reports it (a Windows or IANA name).
+func (rt *runtimeState) mailboxTimeZone(id identityContext) (string, error) {
+	q := url.Values{}
+	q.Set("$select", "timeZone")
+	var out struct {
+		TimeZone string `json:"timeZone"`
+	}
+	if _, err := rt.graphRequest(id, http.MethodGet, mailboxSettingsPath(id), q, nil, &out); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.TimeZone), nil
+}
+
+func runMailAutoreply(rt *runtimeState, id identityContext, args []string) int {
+	if len(args) == 0 || isHelpToken(args[0]) {
+		_, _ = fmt.Fprint(rt.stdout, groupHelp("mail"))
+		return exitcode.Success
+	}
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	switch sub {
+	case "get":
+		if len(args) != 1 {
+			return rt.failErr(usageError("mail autoreply get does not take arguments", "Usage: mo mail autoreply get"))
+		}
+		var out map[string]any
+		if _, err := rt.graphRequest(id, http.MethodGet, mailboxSettingsPath(id)+"/automaticRepliesSetting", nil, nil, &out); err != nil {
+			return rt.failErr(err)
+		}
+		delete(out, "@odata.context")
+		return rt.writeJSON(out)
+	case "set":
+		return runMailAutoreplySet(rt, id, args[1:])
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("unknown mail autoreply subcommand %q", sub), "Run 'mo mail help' for usage."))
+	}
+}
+
+func runMailAutoreplySet(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail autoreply set [--status always|scheduled|disabled] [--internal TEXT] [--external TEXT] [--external-audience none|contactsOnly|all] [--from RFC3339 --to RFC3339]"
+	fs := flag.NewFlagSet("mail autoreply set", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	status := fs.String("status", "", "always|scheduled|disabled (default: scheduled with --from/--to, otherwise always)")
+	internal := fs.String("internal", "", "Reply sent to people in your organization")
+	external := fs.String("external", "", "Reply sent to people outside your organization")
+	audience := fs.String("external-audience", "", "Who outside the organization gets a reply: none|contactsOnly|all")
+	from := fs.String("from", "", "Scheduled start (RFC3339)")
+	to := fs.String("to", "", "Scheduled end (RFC3339)")
+	if err := fs.Parse(args); err != nil {
+		return rt.failErr(usageError("invalid mail autoreply set flags", usage))
+	}
+	if fs.NArg() != 0 {
+		return rt.failErr(usageError("mail autoreply set does not take positional arguments", usage))
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if len(set) == 0 {
+		return rt.failErr(usageError("nothing to update", usage))
+	}
+
+	setting := map[string]any{}
+	scheduled := strings.TrimSpace(*from) != "" || strings.TrimSpace(*to) != ""
+	if scheduled {
+		if strings.TrimSpace(*from) == "" || strings.TrimSpace(*to) == "" {
+			return rt.failErr(usageError("--from and --to must be provided together", usage))
+		}
+		start, err := time.Parse(time.RFC3339, strings.TrimSpace(*from))
+		if err != nil {
+			return rt.failErr(usageError("invalid --from timestamp", "Use RFC3339 format, e.g. 2026-03-02T08:00:00Z."))
+		}
+		end, err := time.Parse(time.RFC3339, strings.TrimSpace(*to))
+		if err != nil {
+			return rt.failErr(usageError("invalid --to timestamp", "Use RFC3339 format, e.g. 2026-03-09T08:00:00Z."))
+		}
+		if !start.Before(end) {
+			return rt.failErr(usageError("--from must be before --to", usage))
+		}
+		setting["scheduledStartDateTime"] = graphDateTime(start)
+		setting["scheduledEndDateTime"] = graphDateTime(end)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(*status)) {
+	case "":
+		if scheduled {
+			setting["status"] = "scheduled"
+		} else if set["internal"] || set["external"] {
+			setting["status"] = "alwaysEnabled"
+		}
+	case "always", "alwaysenabled", "on":
+		setting["status"] = "alwaysEnabled"
+	case "scheduled":
+		if !scheduled {
+			return rt.failErr(usageError("--status scheduled requires --from and --to", usage))
+		}
+		setting["status"] = "scheduled"
+	case "disabled", "off":
+		setting["status"] = "disabled"
+	default:
+		return rt.failErr(usageError(fmt.Sprintf("invalid --status %q", *status), "Use always, scheduled or disabled."))
+	}
+	if set["internal"] {
+		setting["internalReplyMessage"] = *internal
+	}
+	if set["external"] {
+		setting["externalReplyMessage"] = *external
+	}
+	if set["external-audience"] {
+		switch strings.ToLower(strings.TrimSpace(*audience)) {
+		case "none":
+			setting["externalAudience"] = "none"
+		case "contactsonly", "contacts":
+			setting["externalAudience"] = "contactsOnly"
+		case "all":
+			setting["externalAudience"] = "all"
+		default:
+			return rt.failErr(usageError(fmt.Sprintf("invalid --external-audience %q", *audience), "Use none, contactsOnly or all."))
+		}
+	}
+
+	if _, err := rt.graphRequest(id, http.MethodPatch, mailboxSettingsPath(id), nil, map[string]any{"automaticRepliesSetting": setting}, nil); err != nil {
+		return rt.failErr(err)
+	}
+	var out map[string]any
+	if _, err := rt.graphRequest(id, http.MethodGet, mailboxSettingsPath(id)+"/automaticRepliesSetting", nil, nil, &out); err != nil {
+		return rt.failErr(err)
+	}
+	delete(out, "@odata.context")
+	out["updated"] = true
+	return rt.writeJSON(out)
+}
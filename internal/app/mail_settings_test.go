@@ -0,0 +1,130 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+func TestMailAutoreplySetSchedulesWithDates(t *testing.T) {
+	var patched map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPatch {
+			_ = json.NewDecoder(r.Body).Decode(&patched)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"scheduled"}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	code := runMailAutoreplySet(rt, identityContext{}, []string{"--internal", "away", "--from", "2026-03-02T08:00:00Z", "--to", "2026-03-09T08:00:00Z"})
+	if code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	setting, _ := patched["automaticRepliesSetting"].(map[string]any)
+	if setting["status"] != "scheduled" || setting["internalReplyMessage"] != "away" {
+		t.Fatalf("unexpected patch %v", patched)
+	}
+	if _, ok := setting["externalReplyMessage"]; ok {
+		t.Fatalf("external message must not be sent when --external is not given")
+	}
+
+	if code := runMailAutoreplySet(rt, identityContext{}, []string{"--status", "scheduled"}); code == 0 {
+		t.Fatalf("expected usage error for scheduled without dates")
+	}
+}
+
+func TestEventDateTimeAnchorsKnownZones(t *testing.T) {
+	at := time.Date(2026, 7, 1, 7, 0, 0, 0, time.UTC)
+	got := eventDateTime(at, "Europe/Berlin")
+	if got["dateTime"] != "2026-07-01T09:00:00" || got["timeZone"] != "Europe/Berlin" {
+		t.Fatalf("unexpected IANA conversion %v", got)
+	}
+	got = eventDateTime(at, "W. Europe Standard Time")
+	if got["dateTime"] != "2026-07-01T09:00:00" || got["timeZone"] != "W. Europe Standard Time" {
+		t.Fatalf("expected Windows zone to keep local time, got %v", got)
+	}
+	got = eventDateTime(at, "Mars Standard Time")
+	if got["dateTime"] != "2026-07-01T07:00:00Z" || got["timeZone"] != "UTC" {
+		t.Fatalf("expected UTC fallback for unknown zone, got %v", got)
+	}
+}
+
+func TestWindowsTimeZonesLoad(t *testing.T) {
+	for name, iana := range windowsTimeZones {
+		if _, err := time.LoadLocation(iana); err != nil {
+			t.Fatalf("%s maps to %s, which does not load: %v", name, iana, err)
+		}
+	}
+	if loc, ok := loadTimeZone("pacific standard time"); !ok || loc.String() != "America/Los_Angeles" {
+		t.Fatalf("expected case-insensitive Windows lookup, got %v", loc)
+	}
+}
+
+func TestCalendarTimeZoneReadsMailboxOnce(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":"ErrorAccessDenied","message":"Access is denied."}}`))
+	}))
+	defer srv.Close()
+
+	rt := newTestGraphRuntime(srv, &bytes.Buffer{})
+	id := identityContext{Account: "me@contoso.com"}
+	for i := 0; i < 3; i++ {
+		if tz := rt.calendarTimeZone(id, ""); tz != "UTC" {
+			t.Fatalf("expected UTC fallback, got %q", tz)
+		}
+	}
+	warnings := rt.stderr.(*bytes.Buffer).String()
+	if requests != 1 || strings.Count(warnings, "mailbox time zone") != 1 || !strings.Contains(warnings, "--force-consent") {
+		t.Fatalf("expected one request and one re-consent warning, got %d requests and %q", requests, warnings)
+	}
+}
+
+func TestCalendarCommandsRejectUnknownTimeZone(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	id := identityContext{Account: "me@contoso.com"}
+	tz := `Europe/Berln"`
+	cases := []struct {
+		name string
+		run  func(*runtimeState, identityContext, []string) int
+		args []string
+	}{
+		{"list", runCalendarList, nil},
+		{"create", runCalendarCreate, []string{"--summary", "x", "--from", "2026-03-10T09:00:00Z", "--to", "2026-03-10T10:00:00Z"}},
+		{"update", runCalendarUpdate, []string{"evt-1", "--summary", "x"}},
+		{"instances", runCalendarInstances, []string{"series-1", "--from", "2026-03-01T00:00:00Z", "--to", "2026-04-01T00:00:00Z"}},
+	}
+	for _, tc := range cases {
+		if code := tc.run(rt, id, append(tc.args, "--timezone", tz)); code != exitcode.UsageError {
+			t.Fatalf("calendar %s: expected usage error, got %d", tc.name, code)
+		}
+	}
+	if requests != 0 {
+		t.Fatalf("expected no Graph requests, got %d", requests)
+	}
+	if !strings.Contains(rt.stderr.(*bytes.Buffer).String(), "unknown time zone") {
+		t.Fatalf("unexpected errors %q", rt.stderr)
+	}
+}
@@ -0,0 +1,244 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/svaruag/mocli/internal/config"
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const mailSyncSelect = "id,subject,from,receivedDateTime,createdDateTime,lastModifiedDateTime,isRead,bodyPreview,hasAttachments,importance,categories,parentFolderId"
+
+// mailSyncState is the per account and folder checkpoint kept between runs.
+type mailSyncState struct {
+	Account   string `json:"account"`
+	FolderID  string `json:"folder_id"`
+	DeltaLink string `json:"delta_link"`
+	SyncedAt  string `json:"synced_at"`
+	// Seen lists the ids mail watch has already emitted, oldest first.
+	Seen []string `json:"seen,omitempty"`
+}
+
+func runMailSync(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail sync [--folder FOLDER] [--reset] [--since RFC3339]"
+	fs := flag.NewFlagSet("mail sync", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	folder := fs.String("folder", "inbox", "Mail folder id, well-known name, or path such as Inbox/Projects")
+	reset := fs.Bool("reset", false, "Discard the saved delta state and start a full sync")
+	since := fs.String("since", "", "On a full sync, only include messages received after this time (RFC3339)")
+	if err := fs.Parse(args); err != nil {
+		return rt.failErr(usageError("invalid mail sync flags", usage))
+	}
+	if fs.NArg() != 0 {
+		return rt.failErr(usageError("mail sync does not take positional arguments", usage))
+	}
+	if strings.TrimSpace(*since) != "" {
+		if _, err := time.Parse(time.RFC3339, strings.TrimSpace(*since)); err != nil {
+			return rt.failErr(usageError("invalid --since timestamp", "Use RFC3339 format, e.g. 2026-02-18T00:00:00Z."))
+		}
+	}
+
+	folderID, err := rt.resolveMailFolder(id, *folder)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	statePath, err := deltaStatePath(id, "mail", folderID)
+	if err != nil {
+		return rt.failErr(transientError("failed to resolve delta state path", err.Error()))
+	}
+	// Two syncs of the same folder would both replay from the same delta
+	// link and race to save the next one.
+	lock, err := config.Lock("delta-" + strings.TrimSuffix(filepath.Base(statePath), ".json"))
+	if err != nil {
+		return rt.failErr(transientError("failed to lock delta state", err.Error()))
+	}
+	defer lock.Unlock()
+
+	var state mailSyncState
+	if !*reset {
+		state, err = loadMailSyncState(statePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return rt.failErr(usageError("saved delta state is unreadable", err.Error()+"; re-run with --reset."))
+		}
+	}
+
+	startedAt := time.Now().UTC()
+	initial := state.DeltaLink == ""
+	expired := false
+	items, deltaLink, err := rt.mailDelta(context.Background(), id, folderID, state, strings.TrimSpace(*since))
+	if isResyncRequiredErr(err) && !initial {
+		// Graph forgot the sync state; fall back to a full sync.
+		expired, initial = true, true
+		state = mailSyncState{}
+		items, deltaLink, err = rt.mailDelta(context.Background(), id, folderID, state, strings.TrimSpace(*since))
+	}
+	if err != nil {
+		return rt.failErr(err)
+	}
+
+	extra := map[string]any{
+		"folder":    strings.TrimSpace(*folder),
+		"folder_id": folderID,
+		"initial":   initial,
+		"reset":     *reset || expired,
+		"added":     0,
+		"changed":   0,
+		"removed":   0,
+	}
+	for _, it := range items {
+		change := asString(it["change"])
+		extra[change] = extra[change].(int) + 1
+	}
+
+	code := rt.writeItems(func(each func([]map[string]any) error) (string, error) {
+		return "", each(items)
+	}, func(it map[string]any) string {
+		return fmt.Sprintf("%s\t%s\t%s\t%s", asString(it["change"]), asString(it["id"]), asString(it["receivedDateTime"]), strings.ReplaceAll(asString(it["subject"]), "\t", " "))
+	}, extra)
+	if code != exitcode.Success {
+		return code
+	}
+	// Saved only after the changes were written, so an interrupted run
+	// replays them instead of losing them.
+	err = saveMailSyncState(statePath, mailSyncState{
+		Account:   id.Account,
+		FolderID:  folderID,
+		DeltaLink: deltaLink,
+		SyncedAt:  startedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(rt.stderr, "warning: failed to save delta state: %v\n", err)
+	}
+	return code
+}
+
+// mailDelta pages through messages/delta for folderID, starting from the
+// saved delta link when there is one, and returns the changes with a
+// "change" key plus the delta link for the next run. It gives up between
+// pages and retries once ctx is done.
+func (rt *runtimeState) mailDelta(ctx context.Context, id identityContext, folderID string, state mailSyncState, since string) ([]map[string]any, string, error) {
+	u := state.DeltaLink
+	if u == "" {
+		q := url.Values{}
+		q.Set("$select", mailSyncSelect)
+		if since != "" {
+			q.Set("$filter", "receivedDateTime ge "+since)
+		}
+		u = rt.graphURL(mailFolderPath(id, folderID)+"/messages/delta", q)
+	} else if !rt.sameGraphOrigin(u) {
+		return nil, "", usageError("saved delta link does not point at the configured Graph endpoint", "Re-run with --reset.")
+	}
+	var lastSync time.Time
+	if state.SyncedAt != "" {
+		lastSync, _ = time.Parse(time.RFC3339, state.SyncedAt)
+	}
+
+	header := http.Header{}
+	header.Set("Prefer", "odata.maxpagesize=100")
+	items := make([]map[string]any, 0)
+	for {
+		var resp struct {
+			Value     []map[string]any `json:"value"`
+			NextLink  string           `json:"@odata.nextLink"`
+			DeltaLink string           `json:"@odata.deltaLink"`
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		if _, err := rt.graphRequestContext(ctx, id, http.MethodGet, u, header, nil, &resp); err != nil {
+			// Delta queries answer 410 Gone when their sync state expired.
+			var ae *appError
+			if errors.As(err, &ae) && ae.Status == http.StatusGone {
+				return nil, "", resyncRequiredError("delta token expired or invalid", ae.Message)
+			}
+			return nil, "", err
+		}
+		for _, it := range resp.Value {
+			items = append(items, classifyMailChange(it, lastSync))
+		}
+		if resp.DeltaLink != "" {
+			return items, resp.DeltaLink, nil
+		}
+		if resp.NextLink == "" {
+			return nil, "", transientError("delta response had neither nextLink nor deltaLink", "Retry the command.")
+		}
+		if !rt.sameGraphOrigin(resp.NextLink) {
+			return nil, "", transientError("refusing to follow nextLink", fmt.Sprintf("nextLink %q does not point at the configured Graph endpoint", resp.NextLink))
+		}
+		u = resp.NextLink
+	}
+}
+
+// classifyMailChange labels a delta item. Graph does not distinguish new
+// from updated messages, so anything created since the last sync counts as
+// added. A zero lastSync (full sync) makes every message added.
+func classifyMailChange(it map[string]any, lastSync time.Time) map[string]any {
+	if removed, ok := it["@removed"].(map[string]any); ok {
+		return map[string]any{"change": "removed", "id": asString(it["id"]), "reason": asString(removed["reason"])}
+	}
+	change := "added"
+	if !lastSync.IsZero() {
+		created, err := time.Parse(time.RFC3339, asString(it["createdDateTime"]))
+		if err == nil && created.Before(lastSync) {
+			change = "changed"
+		}
+	}
+	it["change"] = change
+	return it
+}
+
+func deltaStatePath(id identityContext, kind, resource string) (string, error) {
+	dir, err := config.DeltaStateDir()
+	if err != nil {
+		return "", err
+	}
+	parts := []string{id.Client, id.Account, kind, resource}
+	if id.Mailbox != "" {
+		// Well-known folder names repeat across mailboxes.
+		parts = append(parts, strings.ToLower(id.Mailbox))
+	}
+	key := strings.Join(parts, "\x00")
+	digest := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, kind+"-"+hex.EncodeToString(digest[:16])+".json"), nil
+}
+
+func loadMailSyncState(path string) (mailSyncState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mailSyncState{}, err
+	}
+	var s mailSyncState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return mailSyncState{}, fmt.Errorf("parse delta state: %w", err)
+	}
+	return s, nil
+}
+
+func saveMailSyncState(path string, s mailSyncState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("ensure delta state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal delta state: %w", err)
+	}
+	return config.WriteFileAtomic(path, append(data, '\n'), 0o600)
+}
+
+func isResyncRequiredErr(err error) bool {
+	var ae *appError
+	return errors.As(err, &ae) && ae.Code == "resync_required"
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMailSyncSavesDeltaLinkAndRecoversFromExpiry(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Query().Get("token") == "stale":
+			w.WriteHeader(http.StatusGone)
+			_, _ = w.Write([]byte(`{"error":{"code":"SyncStateNotFound","message":"expired"}}`))
+		case r.URL.Path == "/v1.0/me/mailFolders/inbox/messages/delta" && r.URL.Query().Get("page") == "":
+			_, _ = w.Write([]byte(`{"value":[{"id":"m1","subject":"hi","createdDateTime":"2026-01-01T00:00:00Z"}],"@odata.nextLink":"` + srv.URL + `/v1.0/me/mailFolders/inbox/messages/delta?page=2"}`))
+		default:
+			_, _ = w.Write([]byte(`{"value":[{"id":"m0","@removed":{"reason":"deleted"}}],"@odata.deltaLink":"` + srv.URL + `/v1.0/me/mailFolders/inbox/messages/delta?token=next"}`))
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	id := identityContext{Account: "a@example.com"}
+	statePath, err := deltaStatePath(id, "mail", "inbox")
+	if err != nil {
+		t.Fatalf("deltaStatePath: %v", err)
+	}
+	if err := saveMailSyncState(statePath, mailSyncState{DeltaLink: srv.URL + "/v1.0/me/mailFolders/inbox/messages/delta?token=stale", SyncedAt: "2026-02-01T00:00:00Z"}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	if code := runMailSync(rt, id, []string{"--folder", "inbox"}); code != 0 {
+		t.Fatalf("expected success, got %d: %s", code, out.String())
+	}
+	var got struct {
+		Initial bool             `json:"initial"`
+		Reset   bool             `json:"reset"`
+		Added   int              `json:"added"`
+		Removed int              `json:"removed"`
+		Items   []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid output: %v", err)
+	}
+	if !got.Initial || !got.Reset || got.Added != 1 || got.Removed != 1 || len(got.Items) != 2 {
+		t.Fatalf("unexpected output %s", out.String())
+	}
+
+	state, err := loadMailSyncState(statePath)
+	if err != nil || filepath.Base(state.DeltaLink) != "delta?token=next" {
+		t.Fatalf("expected new delta link saved, got %+v, %v", state, err)
+	}
+}
+
+func TestClassifyMailChangeUsesLastSync(t *testing.T) {
+	last := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	if c := classifyMailChange(map[string]any{"id": "a", "createdDateTime": "2026-02-02T00:00:00Z"}, last)["change"]; c != "added" {
+		t.Fatalf("expected added, got %v", c)
+	}
+	if c := classifyMailChange(map[string]any{"id": "b", "createdDateTime": "2026-01-02T00:00:00Z"}, last)["change"]; c != "changed" {
+		t.Fatalf("expected changed, got %v", c)
+	}
+}
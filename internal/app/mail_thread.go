@@ -0,0 +1,177 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/exitcode"
+)
+
+const mailThreadSelect = "id,conversationId,subject,from,toRecipients,ccRecipients,sentDateTime,receivedDateTime,isRead,hasAttachments,parentFolderId,uniqueBody"
+
+// quoteIntroRE matches the line mail clients put above a quoted reply, e.g.
+// "On Mon, 2 Feb 2026 at 10:00, Alice <a@example.com> wrote:".
+var quoteIntroRE = regexp.MustCompile(`(?i)^on .{4,200} wrote:$`)
+
+func runMailThread(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail thread <message-id>"
+	if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+		return rt.failErr(usageError("message id is required", usage))
+	}
+	msgID := strings.TrimSpace(args[0])
+
+	q := url.Values{}
+	q.Set("$select", "id,conversationId,subject")
+	var msg struct {
+		ConversationID string `json:"conversationId"`
+		Subject        string `json:"subject"`
+	}
+	if _, err := rt.graphRequest(id, http.MethodGet, messagePath(id, msgID), q, nil, &msg); err != nil {
+		return rt.failErr(err)
+	}
+	if msg.ConversationID == "" {
+		return rt.failErr(notFoundError("message has no conversation id", "Use 'mo mail get' for this message."))
+	}
+
+	// The messages collection spans all folders. Graph rejects $orderby next to a
+	// conversationId filter, so the thread is sorted locally.
+	q = url.Values{}
+	q.Set("$filter", "conversationId eq "+odataString(msg.ConversationID))
+	q.Set("$select", mailThreadSelect)
+	q.Set("$top", "50")
+	header := http.Header{}
+	header.Set("Prefer", `outlook.body-content-type="text"`)
+	messages := make([]map[string]any, 0)
+	_, err := rt.listPages(id, mailRoot(id)+"/messages", q, listOptions{All: true, Header: header}, func(items []map[string]any) error {
+		for _, it := range items {
+			messages = append(messages, threadMessage(it))
+		}
+		return nil
+	})
+	if err != nil {
+		return rt.failErr(err)
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		return asString(messages[i]["sent"]) < asString(messages[j]["sent"])
+	})
+
+	if rt.globals.Plain {
+		for i, m := range messages {
+			if i > 0 {
+				_, _ = fmt.Fprintln(rt.stdout)
+			}
+			_, _ = fmt.Fprintf(rt.stdout, "--- %s\t%s\t%s\n", asString(m["sent"]), asString(m["from"]), asString(m["id"]))
+			if body := asString(m["body"]); body != "" {
+				_, _ = fmt.Fprintln(rt.stdout, body)
+			}
+		}
+		return exitcode.Success
+	}
+	return rt.writeJSON(map[string]any{
+		"conversation_id": msg.ConversationID,
+		"subject":         msg.Subject,
+		"count":           len(messages),
+		"messages":        messages,
+	})
+}
+
+// threadMessage flattens a Graph message into the compact thread shape. The
+// body is Graph's uniqueBody, the part not quoted from earlier messages,
+// with any remaining quoted text stripped.
+func threadMessage(it map[string]any) map[string]any {
+	sent := asString(it["sentDateTime"])
+	if sent == "" {
+		sent = asString(it["receivedDateTime"])
+	}
+	body := ""
+	if ub, ok := it["uniqueBody"].(map[string]any); ok {
+		body = stripQuotedText(asString(ub["content"]))
+	}
+	return map[string]any{
+		"id":              asString(it["id"]),
+		"from":            emailAddressString(it["from"]),
+		"to":              recipientStrings(it["toRecipients"]),
+		"cc":              recipientStrings(it["ccRecipients"]),
+		"sent":            sent,
+		"folder_id":       asString(it["parentFolderId"]),
+		"is_read":         it["isRead"],
+		"has_attachments": it["hasAttachments"],
+		"body":            body,
+	}
+}
+
+func emailAddressString(v any) string {
+	m, _ := v.(map[string]any)
+	addr, _ := m["emailAddress"].(map[string]any)
+	name, email := asString(addr["name"]), asString(addr["address"])
+	switch {
+	case name != "" && email != "" && !strings.EqualFold(name, email):
+		return name + " <" + email + ">"
+	case email != "":
+		return email
+	default:
+		return name
+	}
+}
+
+func recipientStrings(v any) []string {
+	list, _ := v.([]any)
+	out := make([]string, 0, len(list))
+	for _, r := range list {
+		if s := emailAddressString(r); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// stripQuotedText drops ">" quoted lines and cuts the body at the first
+// reply or forward header, then trims blank lines.
+func stripQuotedText(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	blank := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if quoteIntroRE.MatchString(trimmed) || isQuoteSeparator(trimmed, lines[i+1:]) {
+			break
+		}
+		if trimmed == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// isQuoteSeparator recognises Outlook's "-----Original Message-----" and
+// underscore rules, and a "From:" header line followed by "Sent:" or "Date:".
+func isQuoteSeparator(line string, rest []string) bool {
+	switch {
+	case strings.EqualFold(strings.Trim(line, "- "), "Original Message"):
+		return true
+	case len(line) >= 20 && strings.Trim(line, "_") == "":
+		return true
+	case strings.HasPrefix(line, "From:"):
+		for _, next := range rest {
+			next = strings.TrimSpace(next)
+			if next == "" {
+				continue
+			}
+			return strings.HasPrefix(next, "Sent:") || strings.HasPrefix(next, "Date:")
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripQuotedText(t *testing.T) {
+	cases := map[string]string{
+		"Sounds good.\r\n\r\n\r\nThanks\r\n\r\nOn Mon, 2 Feb 2026 at 10:00, Alice <a@example.com> wrote:\r\n> earlier": "Sounds good.\n\nThanks",
+		"Agreed\n\nFrom: Bob\nSent: Monday\nTo: Alice\n\nold text":                                                     "Agreed",
+		"Fine\n-----Original Message-----\nold":                                                                        "Fine",
+		"inline\n> quoted\nreply":                                                                                      "inline\nreply",
+	}
+	for in, want := range cases {
+		if got := stripQuotedText(in); got != want {
+			t.Fatalf("stripQuotedText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMailThreadSortsChronologically(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1.0/me/messages/m2":
+			_, _ = w.Write([]byte(`{"id":"m2","conversationId":"c'1","subject":"Re: plan"}`))
+		case "/v1.0/me/messages":
+			if got := r.URL.Query().Get("$filter"); got != "conversationId eq 'c''1'" {
+				t.Errorf("unexpected filter %q", got)
+			}
+			if r.URL.Query().Get("$orderby") != "" {
+				t.Errorf("$orderby must not be sent with a conversationId filter")
+			}
+			_, _ = w.Write([]byte(`{"value":[
+				{"id":"m2","sentDateTime":"2026-02-02T10:00:00Z","from":{"emailAddress":{"name":"Bob","address":"bob@example.com"}},"uniqueBody":{"content":"yes\n> plan?"}},
+				{"id":"m1","sentDateTime":"2026-02-01T10:00:00Z","from":{"emailAddress":{"address":"alice@example.com"}},"uniqueBody":{"content":"plan?"}}
+			]}`))
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	if code := runMailThread(rt, identityContext{}, []string{"m2"}); code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	var got struct {
+		Count    int              `json:"count"`
+		Messages []map[string]any `json:"messages"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid output: %v", err)
+	}
+	if got.Count != 2 || got.Messages[0]["id"] != "m1" || got.Messages[1]["from"] != "Bob <bob@example.com>" || got.Messages[1]["body"] != "yes" {
+		t.Fatalf("unexpected thread %s", out.String())
+	}
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/svaruag/mocli/internal/config"
+	"github.com/svaruag/mocli/internal/exitcode"
+	"github.com/svaruag/mocli/internal/outfmt"
+)
+
+// mailWatchAuthRetries is how many polls in a row may fail to get a token
+// before watch gives up; a refresh can fail while the network is down.
+const mailWatchAuthRetries = 3
+
+// mailWatchSeenMax bounds the emitted ids kept in the watch checkpoint.
+const mailWatchSeenMax = 10000
+
+func runMailWatch(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo mail watch [--folder FOLDER] [--interval 30s]"
+	fs := flag.NewFlagSet("mail watch", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	folder := fs.String("folder", "inbox", "Mail folder id, well-known name, or path such as Inbox/Projects")
+	interval := fs.Duration("interval", 30*time.Second, "Time between polls")
+	if err := fs.Parse(args); err != nil {
+		return rt.failErr(usageError("invalid mail watch flags", usage))
+	}
+	if fs.NArg() != 0 {
+		return rt.failErr(usageError("mail watch does not take positional arguments", usage))
+	}
+	if *interval < 5*time.Second {
+		return rt.failErr(usageError("--interval must be at least 5s", usage))
+	}
+
+	folderID, err := rt.resolveMailFolder(id, *folder)
+	if err != nil {
+		return rt.failErr(err)
+	}
+	// Watch keeps its own checkpoint so it does not consume changes that
+	// mail sync would otherwise report.
+	statePath, err := deltaStatePath(id, "mail-watch", folderID)
+	if err != nil {
+		return rt.failErr(transientError("failed to resolve delta state path", err.Error()))
+	}
+	lock, err := config.Lock("delta-" + strings.TrimSuffix(filepath.Base(statePath), ".json"))
+	if err != nil {
+		return rt.failErr(transientError("failed to lock watch state", err.Error()+"; is another mail watch running for this folder?"))
+	}
+	defer lock.Unlock()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore the default handlers once the first signal arrives, so a
+		// second Ctrl-C kills a watch that is slow to stop.
+		<-ctx.Done()
+		stop()
+	}()
+	return rt.watchMail(ctx, id, strings.TrimSpace(*folder), folderID, statePath, *interval)
+}
+
+// watchMail polls the folder's delta until ctx is done and streams each new
+// message as an NDJSON record (or a plain line). A message is new when the
+// delta reports an id that no earlier poll did; createdDateTime is not
+// compared with the local clock, which may run ahead of the server. The
+// checkpoint, including the ids already emitted, is saved after every poll,
+// once its messages are written, so a stopped watch resumes where it left
+// off. The first run starts from now.
+func (rt *runtimeState) watchMail(ctx context.Context, id identityContext, folder, folderID, statePath string, interval time.Duration) int {
+	state, err := loadMailSyncState(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return rt.failErr(usageError("saved watch state is unreadable", err.Error()+"; delete "+statePath+" to start over."))
+	}
+	if state.DeltaLink == "" {
+		// Prime with a filter that matches nothing yet, so existing mail is
+		// skipped and the delta link only tracks later arrivals.
+		state = mailSyncState{SyncedAt: time.Now().UTC().Format(time.RFC3339)}
+	}
+
+	w := outfmt.NewNDJSONWriter(rt.stdout)
+	emit := func(it map[string]any) error {
+		if rt.globals.Plain {
+			_, err := fmt.Fprintf(rt.stdout, "%s\t%s\t%s\n", asString(it["id"]), asString(it["receivedDateTime"]), strings.ReplaceAll(asString(it["subject"]), "\t", " "))
+			return err
+		}
+		return w.WriteItem(it)
+	}
+
+	stopped := func() int {
+		if !rt.globals.Plain {
+			_ = w.WriteMeta(map[string]any{"folder": folder, "folder_id": folderID, "stopped": true})
+		}
+		return exitcode.Success
+	}
+
+	// Messages come back in later polls whenever they change; remember
+	// what was already emitted.
+	seen := make(map[string]bool, len(state.Seen))
+	for _, msgID := range state.Seen {
+		seen[msgID] = true
+	}
+	order := state.Seen
+	authFailures := 0
+	for {
+		startedAt := time.Now().UTC()
+		items, deltaLink, err := rt.mailDelta(ctx, id, folderID, state, deltaSince(state))
+		if isResyncRequiredErr(err) {
+			_, _ = fmt.Fprintln(rt.stderr, "warning: delta state expired; resuming from the last poll")
+			state.DeltaLink = ""
+			items, deltaLink, err = rt.mailDelta(ctx, id, folderID, state, deltaSince(state))
+		}
+		if ctx.Err() != nil {
+			// Interrupted mid-poll: keep the last saved checkpoint.
+			return stopped()
+		}
+		if err != nil {
+			var ae *appError
+			retry := errors.As(err, &ae) && (ae.Exit == exitcode.TransientError || ae.Code == "auth_required" && authFailures < mailWatchAuthRetries)
+			if !retry {
+				return rt.failErr(err)
+			}
+			if ae.Code == "auth_required" {
+				authFailures++
+			}
+			_, _ = fmt.Fprintf(rt.stderr, "warning: poll failed, retrying in %s: %v\n", interval, err)
+		} else {
+			authFailures = 0
+			for _, it := range items {
+				msgID := asString(it["id"])
+				if it["change"] == "removed" {
+					delete(seen, msgID)
+					continue
+				}
+				if seen[msgID] {
+					continue
+				}
+				seen[msgID] = true
+				order = append(order, msgID)
+				it["change"] = "added"
+				if err := emit(it); err != nil {
+					return rt.fail("write_failed", "failed to write output", err.Error(), exitcode.UsageError)
+				}
+			}
+			order = trimSeenIDs(order, seen)
+			state = mailSyncState{Account: id.Account, FolderID: folderID, DeltaLink: deltaLink, SyncedAt: startedAt.Format(time.RFC3339), Seen: order}
+			if err := saveMailSyncState(statePath, state); err != nil {
+				_, _ = fmt.Fprintf(rt.stderr, "warning: failed to save watch state: %v\n", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return stopped()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// trimSeenIDs drops ids that were removed since they were emitted and keeps
+// at most mailWatchSeenMax of the most recent ones.
+func trimSeenIDs(order []string, seen map[string]bool) []string {
+	kept := make([]string, 0, len(order))
+	for _, msgID := range order {
+		if seen[msgID] {
+			kept = append(kept, msgID)
+		}
+	}
+	if drop := len(kept) - mailWatchSeenMax; drop > 0 {
+		for _, msgID := range kept[:drop] {
+			delete(seen, msgID)
+		}
+		kept = kept[drop:]
+	}
+	return kept
+}
+
+// deltaSince limits a fresh delta query to messages received since the last
+// poll; an existing delta link carries its own filter.
+func deltaSince(state mailSyncState) string {
+	if state.DeltaLink != "" {
+		return ""
+	}
+	return state.SyncedAt
+}
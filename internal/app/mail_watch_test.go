@@ -0,0 +1,105 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchMailEmitsNewMessagesAndSavesCheckpoint(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+	// The first poll fails through all of graphRequest's retries and watch
+	// must carry on with the next one.
+	var requests atomic.Int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":{"code":"ServiceUnavailable","message":"busy"}}`))
+			return
+		}
+		if requests.Load() == 4 {
+			_, _ = w.Write([]byte(`{"value":[` +
+				`{"id":"first","subject":"hello","createdDateTime":"2999-01-01T00:00:00Z"},` +
+				`{"id":"gone","@removed":{"reason":"deleted"}}` +
+				`],"@odata.deltaLink":"` + srv.URL + `/v1.0/me/mailFolders/inbox/messages/delta?token=next"}`))
+			return
+		}
+		// "first" comes back once it is read; "skewed" arrived between polls
+		// but its createdDateTime is behind the local clock.
+		_, _ = w.Write([]byte(`{"value":[` +
+			`{"id":"first","subject":"hello","isRead":true,"createdDateTime":"2999-01-01T00:00:00Z"},` +
+			`{"id":"skewed","subject":"late","createdDateTime":"2026-01-01T00:00:00Z"}` +
+			`],"@odata.deltaLink":"` + srv.URL + `/v1.0/me/mailFolders/inbox/messages/delta?token=later"}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	id := identityContext{Account: "a@example.com"}
+	statePath, err := deltaStatePath(id, "mail-watch", "inbox")
+	if err != nil {
+		t.Fatalf("deltaStatePath: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			if state, err := loadMailSyncState(statePath); err == nil && strings.HasSuffix(state.DeltaLink, "token=later") {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		cancel()
+	}()
+	if code := rt.watchMail(ctx, id, "inbox", "inbox", statePath, 20*time.Millisecond); code != 0 {
+		t.Fatalf("expected clean exit, got %d: %s", code, out.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"id":"first"`) || !strings.Contains(lines[1], `"id":"skewed"`) || !strings.Contains(lines[2], `"stopped":true`) {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	state, err := loadMailSyncState(statePath)
+	if err != nil || strings.Join(state.Seen, ",") != "first,skewed" {
+		t.Fatalf("expected emitted ids in the checkpoint, got %+v, %v", state, err)
+	}
+}
+
+func TestWatchMailStopsDuringSlowPoll(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	var out bytes.Buffer
+	rt := newTestGraphRuntime(srv, &out)
+	id := identityContext{Account: "a@example.com"}
+	statePath, err := deltaStatePath(id, "mail-watch", "inbox")
+	if err != nil {
+		t.Fatalf("deltaStatePath: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if code := rt.watchMail(ctx, id, "inbox", "inbox", statePath, time.Minute); code != 0 {
+		t.Fatalf("expected clean exit, got %d: %s", code, out.String())
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("watch took %s to stop", elapsed)
+	}
+	if !strings.Contains(out.String(), `"stopped":true`) {
+		t.Fatalf("expected stopped record, got %q", out.String())
+	}
+}
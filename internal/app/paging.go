@@ -0,0 +1,176 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/svaruag/mocli/internal/config"
+	"github.com/svaruag/mocli/internal/exitcode"
+	"github.com/svaruag/mocli/internal/outfmt"
+)
+
+type listOptions struct {
+	Max   int
+	Page  string
+	All   bool
+	Limit int
+
+	// Header is sent with every page request, e.g. Prefer.
+	Header http.Header
+}
+
+func addListFlags(fs *flag.FlagSet, defaultMax int) *listOptions {
+	o := &listOptions{}
+	fs.IntVar(&o.Max, "max", defaultMax, "Max items per page")
+	fs.StringVar(&o.Page, "page", "", "Page token")
+	fs.BoolVar(&o.All, "all", false, "Follow next pages until exhausted")
+	fs.IntVar(&o.Limit, "limit", 0, "Stop after N items (requires --all)")
+	return o
+}
+
+func (o *listOptions) validate() error {
+	if o.Max <= 0 || o.Max > 1000 {
+		return usageError("--max must be between 1 and 1000", "Use a value in range 1..1000.")
+	}
+	if o.Limit < 0 {
+		return usageError("--limit must not be negative", "Use --limit 0 for no ceiling.")
+	}
+	if o.Limit > 0 && !o.All {
+		return usageError("--limit requires --all", "Add --all to follow next pages up to --limit items.")
+	}
+	return nil
+}
+
+func (o *listOptions) apply(q url.Values) {
+	q.Set("$top", strconv.Itoa(o.Max))
+	if strings.TrimSpace(o.Page) != "" {
+		q.Set("$skiptoken", strings.TrimSpace(o.Page))
+	}
+}
+
+// listPages fetches one page, or with --all follows @odata.nextLink verbatim
+// until exhausted or --limit items were seen. each receives items per page as
+// they arrive. The returned token is the next page when not following.
+func (rt *runtimeState) listPages(id identityContext, path string, query url.Values, opts listOptions, each func([]map[string]any) error) (string, error) {
+	u := rt.graphURL(path, query)
+	seen := 0
+	for {
+		var resp struct {
+			Value []map[string]any `json:"value"`
+		}
+		nextLink, err := rt.graphRequestHeader(id, http.MethodGet, u, opts.Header, nil, &resp)
+		if err != nil {
+			return "", err
+		}
+		items := resp.Value
+		if opts.Limit > 0 && seen+len(items) > opts.Limit {
+			items = items[:opts.Limit-seen]
+		}
+		seen += len(items)
+		if err := each(items); err != nil {
+			return "", err
+		}
+
+		if !opts.All {
+			return extractPageToken(nextLink), nil
+		}
+		if nextLink == "" || (opts.Limit > 0 && seen >= opts.Limit) {
+			return "", nil
+		}
+		if !rt.sameGraphOrigin(nextLink) {
+			return "", transientError("refusing to follow nextLink", fmt.Sprintf("nextLink %q does not point at the configured Graph endpoint", nextLink))
+		}
+		u = nextLink
+	}
+}
+
+// writeList runs a list query and writes the standard list document. plain
+// renders one line per item in --plain mode; nil keeps JSON output. Items are
+// streamed as pages arrive; with --output ndjson they are followed by a _meta
+// record carrying next_page and the extra keys.
+func (rt *runtimeState) writeList(id identityContext, path string, query url.Values, opts listOptions, plain func(map[string]any) string, extra map[string]any) int {
+	return rt.writeItems(func(each func([]map[string]any) error) (string, error) {
+		return rt.listPages(id, path, query, opts, each)
+	}, plain, extra)
+}
+
+// writeItems is writeList for item sources that are not a single Graph
+// collection, such as tree walks. fetch passes batches of items to each and
+// returns the next page token, if any.
+func (rt *runtimeState) writeItems(fetch func(each func([]map[string]any) error) (string, error), plain func(map[string]any) string, extra map[string]any) int {
+	if rt.globals.Plain && plain != nil {
+		next, err := fetch(func(items []map[string]any) error {
+			for _, it := range items {
+				_, _ = fmt.Fprintln(rt.stdout, plain(it))
+			}
+			return nil
+		})
+		if err != nil {
+			return rt.failErr(err)
+		}
+		if next != "" {
+			_, _ = fmt.Fprintf(rt.stdout, "next_page\t%s\n", next)
+		}
+		return exitcode.Success
+	}
+
+	if rt.ndjsonMode() {
+		w := outfmt.NewNDJSONWriter(rt.stdout)
+		next, err := fetch(func(items []map[string]any) error {
+			for _, it := range items {
+				if err := w.WriteItem(it); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return rt.failErr(err)
+		}
+		meta := map[string]any{}
+		for k, v := range extra {
+			meta[k] = v
+		}
+		meta["next_page"] = next
+		if err := w.WriteMeta(meta); err != nil {
+			return rt.fail("write_failed", "failed to write output", err.Error(), exitcode.UsageError)
+		}
+		return exitcode.Success
+	}
+
+	// Items are written as pages arrive, so --all never holds the whole
+	// listing. A failure on a later page leaves the document unterminated
+	// and reports the error on stderr with a failing exit code.
+	w := outfmt.NewListWriter(rt.stdout, extra)
+	next, err := fetch(func(items []map[string]any) error {
+		for _, it := range items {
+			if err := w.WriteItem(it); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return rt.failErr(err)
+	}
+	if err := w.Close(next); err != nil {
+		return rt.fail("write_failed", "failed to write output", err.Error(), exitcode.UsageError)
+	}
+	return exitcode.Success
+}
+
+func (rt *runtimeState) sameGraphOrigin(raw string) bool {
+	base, err := url.Parse(strings.TrimSpace(config.String(rt.lookup, "MO_GRAPH_BASE_URL", defaultGraphBaseURL)))
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+}
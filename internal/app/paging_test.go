@@ -0,0 +1,41 @@
+package app
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestListOptionsValidate(t *testing.T) {
+	if err := (&listOptions{Max: 0}).validate(); err == nil {
+		t.Fatalf("expected --max 0 to fail")
+	}
+	if err := (&listOptions{Max: 10, Limit: 5}).validate(); err == nil {
+		t.Fatalf("expected --limit without --all to fail")
+	}
+	if err := (&listOptions{Max: 10, All: true, Limit: 5}).validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestListOptionsApply(t *testing.T) {
+	q := url.Values{}
+	(&listOptions{Max: 25, Page: " tok "}).apply(q)
+	if q.Get("$top") != "25" || q.Get("$skiptoken") != "tok" {
+		t.Fatalf("unexpected query %v", q)
+	}
+}
+
+func TestSameGraphOrigin(t *testing.T) {
+	rt := &runtimeState{lookup: fakeEnv(nil)}
+	if !rt.sameGraphOrigin("https://graph.microsoft.com/v1.0/me/messages?$skiptoken=x") {
+		t.Fatalf("expected default Graph origin to match")
+	}
+	if rt.sameGraphOrigin("https://evil.example.com/v1.0/me/messages") {
+		t.Fatalf("expected foreign origin to be rejected")
+	}
+
+	rt = &runtimeState{lookup: fakeEnv(map[string]string{"MO_GRAPH_BASE_URL": "http://127.0.0.1:8080"})}
+	if !rt.sameGraphOrigin("http://127.0.0.1:8080/v1.0/me/drives?$skiptoken=y") {
+		t.Fatalf("expected overridden Graph origin to match")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/svaruag/mocli/internal/config"
 	"github.com/svaruag/mocli/internal/exitcode"
 	"github.com/svaruag/mocli/internal/outfmt"
+	"github.com/svaruag/mocli/internal/secrets"
 	"github.com/svaruag/mocli/internal/version"
 )
 
@@ -18,6 +19,7 @@ var errUsage = errors.New("usage")
 type Globals struct {
 	JSON    bool
 	Plain   bool
+	Output  string
 	Force   bool
 	NoInput bool
 	Help    bool
@@ -39,6 +41,10 @@ type runtimeState struct {
 	lookup  config.LookupFunc
 
 	endpointWarningsShown bool
+	cachedToken           secrets.AccessToken
+
+	// mailboxZone caches the calendar time zone read from mailboxSettings.
+	mailboxZone string
 }
 
 func Run(args []string, stdout, stderr io.Writer, lookup config.LookupFunc) int {
@@ -87,6 +93,8 @@ func Run(args []string, stdout, stderr io.Writer, lookup config.LookupFunc) int
 		return runDrive(rt, rest)
 	case "config":
 		return runConfig(rt, rest)
+	case "graph":
+		return runGraph(rt, rest)
 	default:
 		return rt.fail("usage_error", fmt.Sprintf("unsupported command %q", cmd), "Run 'mo help' to list commands.", exitcode.UsageError)
 	}
@@ -99,6 +107,7 @@ func parseGlobals(args []string, lookup config.LookupFunc) (parseResult, error)
 		Account: config.String(lookup, "MO_ACCOUNT", ""),
 		Client:  config.String(lookup, "MO_CLIENT", ""),
 		Color:   strings.ToLower(config.String(lookup, "MO_COLOR", "auto")),
+		Output:  strings.ToLower(config.String(lookup, "MO_OUTPUT", "")),
 	}
 
 	fs := flag.NewFlagSet("mo", flag.ContinueOnError)
@@ -107,6 +116,7 @@ func parseGlobals(args []string, lookup config.LookupFunc) (parseResult, error)
 	g := defaults
 	fs.BoolVar(&g.JSON, "json", defaults.JSON, "Output JSON")
 	fs.BoolVar(&g.Plain, "plain", defaults.Plain, "Output plain text")
+	fs.StringVar(&g.Output, "output", defaults.Output, "Output format: json|plain|ndjson")
 	fs.BoolVar(&g.Force, "force", false, "Skip confirmations")
 	fs.BoolVar(&g.NoInput, "no-input", false, "Disable interactive prompts")
 	fs.BoolVar(&g.Help, "help", false, "Show help")
@@ -124,6 +134,22 @@ func parseGlobals(args []string, lookup config.LookupFunc) (parseResult, error)
 		return parseResult{Globals: g}, fmt.Errorf("parse flags: %w", errUsage)
 	}
 
+	// An output flag on the command line overrides every output default
+	// from the environment; conflicts are only reported between flags.
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["json"] || set["plain"] || set["output"] {
+		if !set["json"] {
+			g.JSON = false
+		}
+		if !set["plain"] {
+			g.Plain = false
+		}
+		if !set["output"] {
+			g.Output = ""
+		}
+	}
+
 	g.Color = strings.ToLower(strings.TrimSpace(g.Color))
 	if g.Color == "" {
 		g.Color = "auto"
@@ -135,6 +161,30 @@ func parseGlobals(args []string, lookup config.LookupFunc) (parseResult, error)
 		return parseResult{Globals: g}, fmt.Errorf("--json and --plain cannot be used together")
 	}
 
+	g.Output = strings.ToLower(strings.TrimSpace(g.Output))
+	switch g.Output {
+	case "":
+		g.Output = "json"
+		if g.Plain {
+			g.Output = "plain"
+		}
+	case "json":
+		if g.Plain {
+			return parseResult{Globals: g}, fmt.Errorf("--plain cannot be combined with --output json")
+		}
+	case "plain":
+		if g.JSON {
+			return parseResult{Globals: g}, fmt.Errorf("--json cannot be combined with --output plain")
+		}
+		g.Plain = true
+	case "ndjson":
+		if g.Plain {
+			return parseResult{Globals: g}, fmt.Errorf("--plain cannot be combined with --output ndjson")
+		}
+	default:
+		return parseResult{Globals: g}, fmt.Errorf("invalid --output value %q (allowed: json|plain|ndjson)", g.Output)
+	}
+
 	return parseResult{Globals: g, Rest: fs.Args()}, nil
 }
 
@@ -149,6 +199,10 @@ func (rt *runtimeState) jsonMode() bool {
 	return true
 }
 
+func (rt *runtimeState) ndjsonMode() bool {
+	return rt.globals.Output == "ndjson"
+}
+
 func (rt *runtimeState) fail(code, message, hint string, ec int) int {
 	_ = outfmt.WriteError(rt.stderr, rt.jsonMode(), outfmt.ErrorPayload{
 		Code:    code,
@@ -202,7 +256,7 @@ func parseAllowlist(raw string) map[string]struct{} {
 
 func isKnownCommand(cmd string) bool {
 	switch cmd {
-	case "auth", "mail", "calendar", "tasks", "drive", "config", "version":
+	case "auth", "mail", "calendar", "tasks", "drive", "config", "graph", "version":
 		return true
 	default:
 		return false
@@ -228,6 +282,7 @@ Commands:
   tasks      Task commands
   drive      OneDrive commands
   config     Local configuration commands
+  graph      Raw Microsoft Graph requests
   version    Print version
 
 Help:
@@ -237,6 +292,7 @@ Help:
 Global flags:
   --json
   --plain
+  --output json|plain|ndjson
   --force
   --no-input
   --account <id>
@@ -254,55 +310,101 @@ func groupHelp(group string) string {
 Usage:
   mo auth credentials <path>
   mo auth credentials list
-  mo auth add <email> [--device]
+  mo auth add <email> [--device] [--scope SCOPE]...
   mo auth status
   mo auth list
   mo auth remove <email>`) + "\n"
 	case "mail":
-		return strings.TrimSpace(`mail commands: list, get, send
+		return strings.TrimSpace(`mail commands: list, search, sync, watch, export, get, thread, send, reply, reply-all, forward, draft, move, copy, delete, mark, flag, categorize, categories, folders, folder, attachments, attachment, settings, autoreply, rules
+
+Every mail command accepts --mailbox ADDRESS to act on a shared or delegated mailbox.
 
 Usage:
-  mo mail list [--max N] [--page TOKEN] [--from RFC3339] [--to RFC3339] [--folder ID_OR_NAME]
+  mo mail list [--max N] [--page TOKEN] [--all [--limit N]] [--from RFC3339] [--to RFC3339] [--folder FOLDER] [--search KQL] [filters]
+  mo mail search <KQL> [--folder FOLDER] [--max N] [--page TOKEN] [--all [--limit N]] [filters]
+      filters: [--from-address EMAIL] [--unread] [--has-attachments] [--importance low|normal|high] [--category NAME]
+  mo mail sync [--folder FOLDER] [--reset] [--since RFC3339]
+  mo mail watch [--folder FOLDER] [--interval 30s]
   mo mail get <message-id>
-  mo mail send --to <emails> --subject <text> --body <text> [--cc ...] [--bcc ...] [--body-html]`) + "\n"
+  mo mail thread <message-id>
+  mo mail export <message-id> --out FILE.eml
+  mo mail export --folder FOLDER --format mbox|eml-dir --out PATH [--from RFC3339] [--to RFC3339] [--restart]
+  mo mail send --to <emails> --subject <text> (--body <text> | --body-file PATH|-) [--cc ...] [--bcc ...] [--body-html] [--attach PATH]... [--mailbox ADDRESS [--on-behalf]]
+  mo mail send --mime FILE|-
+  mo mail reply <message-id> [--comment TEXT] [--body-html] [--attach PATH]...
+  mo mail reply-all <message-id> [--comment TEXT] [--body-html] [--attach PATH]...
+  mo mail forward <message-id> --to <emails> [--cc ...] [--bcc ...] [--comment TEXT] [--body-html] [--attach PATH]...
+  mo mail move <message-id>... --folder FOLDER
+  mo mail copy <message-id>... --folder FOLDER
+  mo mail delete <message-id>... [--permanent]
+  mo mail mark <message-id>... --read|--unread
+  mo mail flag <message-id>... --status flagged|complete|notFlagged [--due RFC3339]
+  mo mail categorize <message-id>... [--add NAME]... [--remove NAME]... [--clear]
+  mo mail categories list
+  mo mail categories create <name> [--color COLOR]
+  mo mail categories delete <name|id>
+  mo mail folders [--parent FOLDER] [--recursive]
+  mo mail folder create <name|parent/path/name> [--parent FOLDER]
+  mo mail folder rename <folder> <new-name>
+  mo mail folder delete <folder>
+  mo mail draft create [--to <emails>] [--subject <text>] [--body <text> | --body-file PATH|-] [--cc ...] [--bcc ...] [--body-html] [--attach PATH]...
+  mo mail draft update <draft-id> [--to ...] [--cc ...] [--bcc ...] [--subject ...] [--body ... | --body-file PATH|-] [--body-html] [--attach PATH]... [--remove-attachment ID]...
+  mo mail draft list [--max N] [--page TOKEN] [--all [--limit N]]
+  mo mail draft send <draft-id>
+  mo mail draft delete <draft-id>
+  mo mail attachments <message-id>
+  mo mail attachment download <message-id> <attachment-id> [--out PATH]
+  mo mail settings get
+  mo mail autoreply get
+  mo mail autoreply set [--status always|scheduled|disabled] [--internal TEXT] [--external TEXT] [--external-audience none|contactsOnly|all] [--from RFC3339 --to RFC3339]
+  mo mail rules list
+  mo mail rules get <rule-id>
+  mo mail rules create <rule.json|rule.yaml|->
+  mo mail rules update <rule-id> <rule.json|rule.yaml|->
+  mo mail rules delete <rule-id>
+  mo mail rules apply <rules.json|rules.yaml|-> [--prune] [--dry-run]`) + "\n"
 	case "calendar":
-		return strings.TrimSpace(`calendar commands: list, create, update, delete
+		return strings.TrimSpace(`calendar commands: list, create, update, delete, instances, calendars
 
 Usage:
-  mo calendar list [--from RFC3339 --to RFC3339] [--max N] [--page TOKEN]
-  mo calendar create --summary <text> --from <RFC3339> --to <RFC3339> [--description ...] [--location ...] [--attendees ...]
-  mo calendar update <event-id> [--summary ...] [--from ...] [--to ...] [--description ...] [--location ...] [--attendees ...]
-  mo calendar delete <event-id>`) + "\n"
+  mo calendar list [--from RFC3339 --to RFC3339] [--max N] [--page TOKEN] [--all [--limit N]] [--timezone TZ] [--calendar CALENDAR]
+  mo calendar create --summary <text> --from <RFC3339> --to <RFC3339> [--description ...] [--location ...] [--attendees ...] [--timezone TZ] [--calendar CALENDAR] [--repeat daily|weekly|monthly|yearly [--interval N] [--days MO,WE] [--until DATE|--count N] | --rrule RULE]
+  mo calendar update <event-id> [--summary ...] [--from ...] [--to ...] [--description ...] [--location ...] [--attendees ...] [--timezone TZ] [--calendar CALENDAR] [--series|--occurrence DATE]
+  mo calendar delete <event-id> [--calendar CALENDAR] [--series|--occurrence DATE]
+  mo calendar instances <series-id> --from RFC3339 --to RFC3339 [--max N] [--page TOKEN] [--all [--limit N]] [--timezone TZ] [--calendar CALENDAR]
+  mo calendar calendars [list] [--user EMAIL]
+  mo calendar calendars create <name> [--color COLOR]
+  mo calendar calendars delete <calendar>`) + "\n"
 	case "tasks":
 		return strings.TrimSpace(`tasks commands: list, create, update, complete, delete
 
 Usage:
-  mo tasks list [--list-id ID] [--max N] [--page TOKEN]
+  mo tasks list [--list-id ID] [--max N] [--page TOKEN] [--all [--limit N]]
   mo tasks create --title <text> [--list-id ID] [--body ...] [--due RFC3339] [--status ...] [--importance ...]
   mo tasks update <task-id> [--list-id ID] [--title ...] [--body ...] [--due ...] [--status ...] [--importance ...]
-  mo tasks complete <task-id> [--list-id ID]
-  mo tasks delete <task-id> [--list-id ID]`) + "\n"
+  mo tasks complete <task-id>... [--list-id ID]
+  mo tasks delete <task-id>... [--list-id ID]`) + "\n"
 	case "drive":
 		return strings.TrimSpace(`drive commands: ls, search, get, upload, download, mkdir, rename, move, delete, permissions, share, unshare, comments, comment, drives, shared
 
 Usage:
-  mo drive ls [--parent ID] [--max N] [--page TOKEN] [--drive DRIVE_ID]
-  mo drive search <text> [--max N] [--page TOKEN] [--drive DRIVE_ID]
+  mo drive ls [--parent ID] [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]
+  mo drive search <text> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]
   mo drive get <item-id> [--drive DRIVE_ID]
-  mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--drive DRIVE_ID]
+  mo drive upload <local-path> [--parent ID] [--name NAME] [--conflict fail|rename|replace] [--chunk-size SIZE] [--resume] [--drive DRIVE_ID]
   mo drive download <item-id> [--out PATH] [--drive DRIVE_ID]
   mo drive mkdir <name> [--parent ID] [--drive DRIVE_ID]
   mo drive rename <item-id> <new-name> [--drive DRIVE_ID]
   mo drive move <item-id> --parent <dest-id> [--drive DRIVE_ID]
   mo drive delete <item-id> [--permanent] [--drive DRIVE_ID]
-  mo drive permissions <item-id> [--max N] [--page TOKEN] [--drive DRIVE_ID]
+  mo drive permissions <item-id> [--max N] [--page TOKEN] [--all [--limit N]] [--drive DRIVE_ID]
   mo drive share <item-id> --to user|domain|anyone [--email ...] [--domain ...] --role read|write [--send-invite] [--drive DRIVE_ID]
   mo drive unshare <item-id> <permission-id> [--drive DRIVE_ID]
   mo drive comments <item-id> [--max N] [--page TOKEN] [--drive DRIVE_ID]
   mo drive comment add <item-id> --text <value> [--drive DRIVE_ID]
   mo drive comment delete <item-id> <comment-id> [--drive DRIVE_ID]
-  mo drive drives [--max N] [--page TOKEN]
-  mo drive shared [--max N] [--page TOKEN]`) + "\n"
+  mo drive drives [--max N] [--page TOKEN] [--all [--limit N]]
+  mo drive shared [--max N] [--page TOKEN] [--all [--limit N]]`) + "\n"
 	case "config":
 		return strings.TrimSpace(`config commands: list, get, set, unset, path
 
@@ -312,6 +414,13 @@ Usage:
   mo config set <key> <value>
   mo config unset <key>
   mo config path`) + "\n"
+	case "graph":
+		return strings.TrimSpace(`graph: raw Microsoft Graph request with mo auth, retries and error mapping
+
+Usage:
+  mo graph <GET|POST|PATCH|PUT|DELETE> <path> [--query QUERY]... [--header 'Name: value']... [--body JSON|@file|@-] [--all [--limit N]]
+
+Paths without a version prefix default to /v1.0.`) + "\n"
 	default:
 		return "help\n"
 	}
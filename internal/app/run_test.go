@@ -209,3 +209,60 @@ func TestRunDriveHelpCommand(t *testing.T) {
 		t.Fatalf("expected drive help output, got %q", out.String())
 	}
 }
+
+func TestParseGlobalsOutputFormat(t *testing.T) {
+	parsed, err := parseGlobals([]string{"--output", "NDJSON", "version"}, fakeEnv(nil))
+	if err != nil {
+		t.Fatalf("parseGlobals returned error: %v", err)
+	}
+	if parsed.Globals.Output != "ndjson" {
+		t.Fatalf("expected ndjson output, got %q", parsed.Globals.Output)
+	}
+
+	parsed, err = parseGlobals([]string{"version"}, fakeEnv(map[string]string{"MO_OUTPUT": "plain"}))
+	if err != nil {
+		t.Fatalf("parseGlobals returned error: %v", err)
+	}
+	if !parsed.Globals.Plain {
+		t.Fatalf("expected MO_OUTPUT=plain to enable plain mode")
+	}
+
+	if _, err := parseGlobals([]string{"--plain", "--output", "ndjson", "version"}, fakeEnv(nil)); err == nil {
+		t.Fatalf("expected --plain with --output ndjson to fail")
+	}
+	if _, err := parseGlobals([]string{"--json", "--plain", "version"}, fakeEnv(nil)); err == nil {
+		t.Fatalf("expected --json with --plain to fail")
+	}
+	if _, err := parseGlobals([]string{"--output", "yaml", "version"}, fakeEnv(nil)); err == nil {
+		t.Fatalf("expected invalid --output to fail")
+	}
+}
+
+func TestParseGlobalsFlagOverridesEnvOutput(t *testing.T) {
+	cases := []struct {
+		env    map[string]string
+		args   []string
+		output string
+		plain  bool
+		json   bool
+	}{
+		{map[string]string{"MO_PLAIN": "1"}, []string{"--output", "json"}, "json", false, false},
+		{map[string]string{"MO_PLAIN": "1"}, []string{"--output", "ndjson"}, "ndjson", false, false},
+		{map[string]string{"MO_PLAIN": "1"}, []string{"--json"}, "json", false, true},
+		{map[string]string{"MO_JSON": "1"}, []string{"--plain"}, "plain", true, false},
+		{map[string]string{"MO_JSON": "1"}, []string{"--output", "plain"}, "plain", true, false},
+		{map[string]string{"MO_OUTPUT": "ndjson"}, []string{"--plain"}, "plain", true, false},
+		{map[string]string{"MO_OUTPUT": "plain"}, []string{"--json"}, "json", false, true},
+		{map[string]string{"MO_OUTPUT": "plain"}, nil, "plain", true, false},
+	}
+	for _, tc := range cases {
+		parsed, err := parseGlobals(append(tc.args, "version"), fakeEnv(tc.env))
+		if err != nil {
+			t.Fatalf("env %v args %v: %v", tc.env, tc.args, err)
+		}
+		g := parsed.Globals
+		if g.Output != tc.output || g.Plain != tc.plain || g.JSON != tc.json {
+			t.Fatalf("env %v args %v: got output=%q plain=%v json=%v", tc.env, tc.args, g.Output, g.Plain, g.JSON)
+		}
+	}
+}
@@ -58,17 +58,16 @@ func runTasks(rt *runtimeState, args []string) int {
 func runTasksList(rt *runtimeState, id identityContext, args []string) int {
 	fs := flag.NewFlagSet("tasks list", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	max := fs.Int("max", 100, "Max tasks")
-	page := fs.String("page", "", "Page token")
+	list := addListFlags(fs, 100)
 	listID := fs.String("list-id", "", "To Do list id")
 	if err := fs.Parse(args); err != nil {
-		return rt.failErr(usageError("invalid tasks list flags", "Usage: mo tasks list [--list-id ID] [--max N] [--page TOKEN]"))
+		return rt.failErr(usageError("invalid tasks list flags", "Usage: mo tasks list [--list-id ID] [--max N] [--page TOKEN] [--all [--limit N]]"))
 	}
 	if fs.NArg() != 0 {
 		return rt.failErr(usageError("tasks list does not take positional arguments", "Run 'mo tasks list --help'."))
 	}
-	if *max <= 0 || *max > 1000 {
-		return rt.failErr(usageError("--max must be between 1 and 1000", "Use a value in range 1..1000."))
+	if err := list.validate(); err != nil {
+		return rt.failErr(err)
 	}
 
 	resolvedListID, err := resolveTodoListID(rt, id, *listID)
@@ -77,34 +76,15 @@ func runTasksList(rt *runtimeState, id identityContext, args []string) int {
 	}
 
 	q := url.Values{}
-	q.Set("$top", fmt.Sprintf("%d", *max))
-	if strings.TrimSpace(*page) != "" {
-		q.Set("$skiptoken", strings.TrimSpace(*page))
-	}
+	list.apply(q)
 
 	path := "/v1.0/me/todo/lists/" + url.PathEscape(resolvedListID) + "/tasks"
-	var resp struct {
-		Value []map[string]any `json:"value"`
-	}
-	next, err := rt.graphRequest(id, "GET", path, q, nil, &resp)
-	if err != nil {
-		return rt.failErr(err)
-	}
-
-	if rt.globals.Plain {
-		for _, it := range resp.Value {
-			idv, _ := it["id"].(string)
-			title, _ := it["title"].(string)
-			status, _ := it["status"].(string)
-			_, _ = fmt.Fprintf(rt.stdout, "%s\t%s\t%s\n", idv, status, strings.ReplaceAll(title, "\t", " "))
-		}
-		if next != "" {
-			_, _ = fmt.Fprintf(rt.stdout, "next_page\t%s\n", next)
-		}
-		return exitcode.Success
-	}
-
-	return rt.writeJSON(map[string]any{"list_id": resolvedListID, "items": resp.Value, "next_page": next})
+	return rt.writeList(id, path, q, *list, func(it map[string]any) string {
+		idv, _ := it["id"].(string)
+		title, _ := it["title"].(string)
+		status, _ := it["status"].(string)
+		return fmt.Sprintf("%s\t%s\t%s", idv, status, strings.ReplaceAll(title, "\t", " "))
+	}, map[string]any{"list_id": resolvedListID})
 }
 
 func runTasksCreate(rt *runtimeState, id identityContext, args []string) int {
@@ -232,18 +212,17 @@ func runTasksUpdate(rt *runtimeState, id identityContext, args []string) int {
 }
 
 func runTasksComplete(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo tasks complete <task-id>... [--list-id ID]"
 	fs := flag.NewFlagSet("tasks complete", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	listID := fs.String("list-id", "", "To Do list id")
-	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
-		return rt.failErr(usageError("invalid tasks complete flags", "Usage: mo tasks complete <task-id> [--list-id ID]"))
-	}
-	if fs.NArg() != 1 {
-		return rt.failErr(usageError("task id is required", "Usage: mo tasks complete <task-id> [--list-id ID]"))
+	rest, err := parseFlagsAndIDs(fs, args)
+	if err != nil {
+		return rt.failErr(usageError("invalid tasks complete flags", usage))
 	}
-	taskID := strings.TrimSpace(fs.Arg(0))
-	if taskID == "" {
-		return rt.failErr(usageError("task id is required", "Usage: mo tasks complete <task-id> [--list-id ID]"))
+	taskIDs, err := positionalIDs(rest, "task id", usage)
+	if err != nil {
+		return rt.failErr(err)
 	}
 	resolvedListID, err := resolveTodoListID(rt, id, *listID)
 	if err != nil {
@@ -257,9 +236,15 @@ func runTasksComplete(rt *runtimeState, id identityContext, args []string) int {
 			"timeZone": "UTC",
 		},
 	}
-	path := "/v1.0/me/todo/lists/" + url.PathEscape(resolvedListID) + "/tasks/" + url.PathEscape(taskID)
+	if len(taskIDs) > 1 {
+		return rt.writeBatchResults(id, taskIDs, "completed", func(taskID string) batchRequest {
+			return batchRequest{Method: "PATCH", URL: todoTaskPath(resolvedListID, taskID), Body: payload}
+		}, nil, map[string]any{"list_id": resolvedListID})
+	}
+
+	taskID := taskIDs[0]
 	var out map[string]any
-	_, err = rt.graphRequest(id, "PATCH", path, nil, payload, &out)
+	_, err = rt.graphRequest(id, "PATCH", todoTaskPath(resolvedListID, taskID), nil, payload, &out)
 	if err != nil {
 		return rt.failErr(err)
 	}
@@ -270,39 +255,55 @@ func runTasksComplete(rt *runtimeState, id identityContext, args []string) int {
 }
 
 func runTasksDelete(rt *runtimeState, id identityContext, args []string) int {
+	const usage = "Usage: mo tasks delete <task-id>... [--list-id ID]"
 	fs := flag.NewFlagSet("tasks delete", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 	listID := fs.String("list-id", "", "To Do list id")
-	if err := fs.Parse(normalizeOnePositionalArgs(args)); err != nil {
-		return rt.failErr(usageError("invalid tasks delete flags", "Usage: mo tasks delete <task-id> [--list-id ID]"))
-	}
-	if fs.NArg() != 1 {
-		return rt.failErr(usageError("task id is required", "Usage: mo tasks delete <task-id> [--list-id ID]"))
+	rest, err := parseFlagsAndIDs(fs, args)
+	if err != nil {
+		return rt.failErr(usageError("invalid tasks delete flags", usage))
 	}
-	taskID := strings.TrimSpace(fs.Arg(0))
-	if taskID == "" {
-		return rt.failErr(usageError("task id is required", "Usage: mo tasks delete <task-id> [--list-id ID]"))
+	taskIDs, err := positionalIDs(rest, "task id", usage)
+	if err != nil {
+		return rt.failErr(err)
 	}
 	resolvedListID, err := resolveTodoListID(rt, id, *listID)
 	if err != nil {
 		return rt.failErr(err)
 	}
-	ok, err := confirmAction(rt, "Delete task?")
+	prompt := "Delete task?"
+	if len(taskIDs) > 1 {
+		prompt = fmt.Sprintf("Delete %d tasks?", len(taskIDs))
+	}
+	ok, err := confirmAction(rt, prompt)
 	if err != nil {
 		return rt.failErr(err)
 	}
 	if !ok {
-		return rt.writeJSON(map[string]any{"deleted": false, "id": taskID, "list_id": resolvedListID})
+		if len(taskIDs) > 1 {
+			return rt.writeJSON(map[string]any{"deleted": false, "ids": taskIDs, "list_id": resolvedListID})
+		}
+		return rt.writeJSON(map[string]any{"deleted": false, "id": taskIDs[0], "list_id": resolvedListID})
 	}
 
-	path := "/v1.0/me/todo/lists/" + url.PathEscape(resolvedListID) + "/tasks/" + url.PathEscape(taskID)
-	_, err = rt.graphRequest(id, "DELETE", path, nil, nil, nil)
+	if len(taskIDs) > 1 {
+		return rt.writeBatchResults(id, taskIDs, "deleted", func(taskID string) batchRequest {
+			return batchRequest{Method: "DELETE", URL: todoTaskPath(resolvedListID, taskID)}
+		}, nil, map[string]any{"list_id": resolvedListID})
+	}
+
+	taskID := taskIDs[0]
+	_, err = rt.graphRequest(id, "DELETE", todoTaskPath(resolvedListID, taskID), nil, nil, nil)
 	if err != nil {
 		return rt.failErr(err)
 	}
 	return rt.writeJSON(map[string]any{"deleted": true, "id": taskID, "list_id": resolvedListID})
 }
 
+func todoTaskPath(listID, taskID string) string {
+	return "/v1.0/me/todo/lists/" + url.PathEscape(listID) + "/tasks/" + url.PathEscape(taskID)
+}
+
 func resolveTodoListID(rt *runtimeState, id identityContext, listID string) (string, error) {
 	if strings.TrimSpace(listID) != "" {
 		return strings.TrimSpace(listID), nil
@@ -22,13 +22,64 @@ var DefaultScopes = []string{
 	"profile",
 	"offline_access",
 	"User.Read",
-	"Mail.Read",
+	"Mail.ReadWrite",
 	"Mail.Send",
+	"MailboxSettings.ReadWrite",
 	"Calendars.ReadWrite",
 	"Tasks.ReadWrite",
 	"Files.ReadWrite",
 }
 
+// RefreshScopes returns the scopes to request when redeeming a refresh token.
+// It reuses the scopes granted at login so that adding scopes to
+// DefaultScopes does not break refresh for accounts that have not
+// re-consented. offline_access is always kept so the token keeps rotating.
+func RefreshScopes(granted string) []string {
+	fields := strings.Fields(granted)
+	if len(fields) == 0 {
+		return DefaultScopes
+	}
+	out := make([]string, 0, len(fields)+1)
+	hasOffline := false
+	for _, f := range fields {
+		if strings.EqualFold(f, "offline_access") {
+			hasOffline = true
+		}
+		out = append(out, f)
+	}
+	if !hasOffline {
+		out = append(out, "offline_access")
+	}
+	return out
+}
+
+// MissingScopes returns the scopes in want that granted (a space separated
+// scope string) does not cover. A ReadWrite scope covers its Read
+// counterpart, and resource prefixes such as https://graph.microsoft.com/
+// are ignored.
+func MissingScopes(granted string, want []string) []string {
+	have := map[string]bool{}
+	for _, f := range strings.Fields(granted) {
+		f = strings.ToLower(f[strings.LastIndex(f, "/")+1:])
+		have[f] = true
+		if name, rest, ok := strings.Cut(f, ".readwrite"); ok {
+			have[name+".read"+rest] = true
+		}
+	}
+	var missing []string
+	for _, w := range want {
+		if !have[strings.ToLower(w)] {
+			missing = append(missing, w)
+		}
+	}
+	return missing
+}
+
+// HasScopes reports whether granted covers every scope in want.
+func HasScopes(granted string, want []string) bool {
+	return len(MissingScopes(granted, want)) == 0
+}
+
 var newHTTPClient = func(timeout time.Duration) *http.Client {
 	return &http.Client{Timeout: timeout}
 }
@@ -30,6 +30,27 @@ func TestParseRedirectURLError(t *testing.T) {
 	}
 }
 
+func TestRefreshScopesKeepsGrantedScopes(t *testing.T) {
+	got := RefreshScopes("User.Read Mail.Read openid")
+	want := "User.Read Mail.Read openid offline_access"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("RefreshScopes = %q, want %q", strings.Join(got, " "), want)
+	}
+	if got := RefreshScopes(""); len(got) != len(DefaultScopes) {
+		t.Fatalf("expected DefaultScopes fallback, got %v", got)
+	}
+}
+
+func TestMissingScopes(t *testing.T) {
+	granted := "openid https://graph.microsoft.com/Mail.ReadWrite.Shared Mail.Send"
+	if got := MissingScopes(granted, []string{"Mail.Read.Shared", "mail.send"}); len(got) != 0 {
+		t.Fatalf("expected ReadWrite to cover Read, got missing %v", got)
+	}
+	if got := MissingScopes(granted, []string{"Mail.Send.Shared"}); len(got) != 1 || got[0] != "Mail.Send.Shared" {
+		t.Fatalf("expected Mail.Send.Shared missing, got %v", got)
+	}
+}
+
 func TestStartDeviceCode(t *testing.T) {
 	useMockHTTPClient(t, func(r *http.Request) (*http.Response, error) {
 		if r.URL.Path != "/common/oauth2/v2.0/devicecode" {
@@ -61,12 +61,35 @@ func SaveAppConfig(cfg AppConfig) error {
 		return fmt.Errorf("marshal config: %w", err)
 	}
 	path := dir + string(os.PathSeparator) + "config.json"
-	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+	if err := WriteFileAtomic(path, append(data, '\n'), 0o600); err != nil {
 		return fmt.Errorf("write config: %w", err)
 	}
 	return nil
 }
 
+// UpdateAppConfig applies fn to the current config and saves the result while
+// holding the config lock, so concurrent processes do not drop each other's
+// changes.
+func UpdateAppConfig(fn func(*AppConfig) error) (AppConfig, error) {
+	lock, err := Lock("config")
+	if err != nil {
+		return AppConfig{}, err
+	}
+	defer lock.Unlock()
+
+	cfg, err := LoadAppConfig()
+	if err != nil {
+		return AppConfig{}, err
+	}
+	if err := fn(&cfg); err != nil {
+		return AppConfig{}, err
+	}
+	if err := SaveAppConfig(cfg); err != nil {
+		return AppConfig{}, err
+	}
+	return cfg, nil
+}
+
 func (c *AppConfig) normalize() {
 	c.KeyringBackend = strings.ToLower(strings.TrimSpace(c.KeyringBackend))
 	c.DefaultAccount = strings.ToLower(strings.TrimSpace(c.DefaultAccount))
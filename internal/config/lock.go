@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	lockTimeout      = 30 * time.Second
+	lockPollInterval = 50 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when another process held a lock for longer
+// than the lock timeout.
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// FileLock is an exclusive advisory lock held on a file in the locks
+// directory. Locks coordinate concurrent mo processes; they do not protect
+// against other programs writing the same files.
+type FileLock struct {
+	f *os.File
+}
+
+// Lock acquires the named cross-process lock, waiting up to the lock timeout.
+func Lock(name string) (*FileLock, error) {
+	dir, err := LocksDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("ensure locks dir: %w", err)
+	}
+	return lockPath(filepath.Join(dir, normalizeClientName(name)+".lock"), lockTimeout)
+}
+
+func lockPath(path string, timeout time.Duration) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if ok {
+			return &FileLock{f: f}, nil
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, ErrLockTimeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Unlock releases the lock. It is safe to call on a nil lock.
+func (l *FileLock) Unlock() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	return err
+}
+
+// WriteFileAtomic writes data to a temp file in the target directory and
+// renames it over path, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		cleanup()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
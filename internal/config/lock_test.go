@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockBlocksSecondHolder(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+
+	first, err := Lock("token:default:user@example.com")
+	if err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	dir, _ := LocksDir()
+	path := filepath.Join(dir, normalizeClientName("token:default:user@example.com")+".lock")
+	if _, err := lockPath(path, 100*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout while held, got %v", err)
+	}
+
+	if err := first.Unlock(); err != nil {
+		t.Fatalf("Unlock returned error: %v", err)
+	}
+	second, err := lockPath(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected lock after release, got %v", err)
+	}
+	_ = second.Unlock()
+}
+
+func TestWriteFileAtomicReplacesContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("new"), 0o600); err != nil {
+		t.Fatalf("WriteFileAtomic returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("unexpected content %q (%v)", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected temp file to be renamed away, found %d entries", len(entries))
+	}
+}
+
+func TestUpdateAppConfigConcurrentWritersKeepAllAccounts(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+
+	emails := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"}
+	var wg sync.WaitGroup
+	for _, email := range emails {
+		wg.Add(1)
+		go func(email string) {
+			defer wg.Done()
+			if _, err := UpdateAppConfig(func(cfg *AppConfig) error {
+				cfg.UpsertAccount("default", email)
+				return nil
+			}); err != nil {
+				t.Errorf("UpdateAppConfig returned error: %v", err)
+			}
+		}(email)
+	}
+	wg.Wait()
+
+	cfg, err := LoadAppConfig()
+	if err != nil {
+		t.Fatalf("LoadAppConfig returned error: %v", err)
+	}
+	if got := len(cfg.AccountsForClient("default")); got != len(emails) {
+		t.Fatalf("expected %d accounts, got %d", len(emails), got)
+	}
+}
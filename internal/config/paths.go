@@ -69,6 +69,30 @@ func OAuthStateDir() (string, error) {
 	return filepath.Join(dir, "state", "oauth"), nil
 }
 
+func UploadStateDir() (string, error) {
+	dir, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state", "uploads"), nil
+}
+
+func DeltaStateDir() (string, error) {
+	dir, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state", "delta"), nil
+}
+
+func LocksDir() (string, error) {
+	dir, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "locks"), nil
+}
+
 func normalizeClientName(v string) string {
 	v = safeNameRE.ReplaceAllString(v, "-")
 	if v == "" {
@@ -1,9 +1,11 @@
 package outfmt
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 )
 
 type ErrorPayload struct {
@@ -22,6 +24,150 @@ func WriteJSON(w io.Writer, v any) error {
 	return enc.Encode(v)
 }
 
+// NDJSONWriter emits one compact JSON document per line so large listings can
+// be consumed incrementally.
+type NDJSONWriter struct {
+	enc   *json.Encoder
+	count int
+}
+
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &NDJSONWriter{enc: enc}
+}
+
+func (n *NDJSONWriter) WriteItem(v any) error {
+	if err := n.enc.Encode(v); err != nil {
+		return err
+	}
+	n.count++
+	return nil
+}
+
+// WriteMeta writes the trailing {"_meta": {...}} record, including the number
+// of items written.
+func (n *NDJSONWriter) WriteMeta(meta map[string]any) error {
+	out := map[string]any{"count": n.count}
+	for k, v := range meta {
+		out[k] = v
+	}
+	return n.enc.Encode(map[string]any{"_meta": out})
+}
+
+// ListWriter streams the {"items": [...], "next_page": ...} list document
+// one item at a time. Its output matches WriteJSON of the whole document, so
+// callers can follow pages without holding every item in memory. Nothing is
+// written until the first item or Close.
+type ListWriter struct {
+	w       io.Writer
+	extra   map[string]any
+	started bool
+	count   int
+}
+
+// NewListWriter returns a ListWriter whose document also carries extra.
+func NewListWriter(w io.Writer, extra map[string]any) *ListWriter {
+	return &ListWriter{w: w, extra: extra}
+}
+
+func (l *ListWriter) WriteItem(v any) error {
+	if err := l.begin(); err != nil {
+		return err
+	}
+	data, err := encodeCompact(v)
+	if err != nil {
+		return err
+	}
+	if l.count > 0 {
+		data = append([]byte{','}, data...)
+	}
+	if _, err := l.w.Write(data); err != nil {
+		return err
+	}
+	l.count++
+	return nil
+}
+
+// Close ends the items array and writes next_page and the remaining extra
+// keys.
+func (l *ListWriter) Close(nextPage string) error {
+	if err := l.begin(); err != nil {
+		return err
+	}
+	tail := map[string]any{"next_page": nextPage}
+	for k, v := range l.extra {
+		if k > "items" && k != "next_page" {
+			tail[k] = v
+		}
+	}
+	buf := []byte{']'}
+	for _, k := range sortedKeys(tail) {
+		field, err := encodeField(k, tail[k])
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, ','), field...)
+	}
+	buf = append(buf, '}', '\n')
+	_, err := l.w.Write(buf)
+	return err
+}
+
+// begin writes the keys sorting before "items" and opens the array, the way
+// encoding/json orders map keys.
+func (l *ListWriter) begin() error {
+	if l.started {
+		return nil
+	}
+	l.started = true
+	buf := []byte{'{'}
+	for _, k := range sortedKeys(l.extra) {
+		if k >= "items" {
+			continue
+		}
+		field, err := encodeField(k, l.extra[k])
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, field...), ',')
+	}
+	buf = append(buf, `"items":[`...)
+	_, err := l.w.Write(buf)
+	return err
+}
+
+func encodeField(key string, v any) ([]byte, error) {
+	k, err := encodeCompact(key)
+	if err != nil {
+		return nil, err
+	}
+	val, err := encodeCompact(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(k, ':'), val...), nil
+}
+
+func encodeCompact(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func WriteError(w io.Writer, jsonMode bool, payload ErrorPayload) error {
 	if jsonMode {
 		return WriteJSON(w, ErrorEnvelope{Error: payload})
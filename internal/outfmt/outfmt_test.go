@@ -0,0 +1,68 @@
+package outfmt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+	if err := w.WriteItem(map[string]any{"id": "a<b"}); err != nil {
+		t.Fatalf("WriteItem returned error: %v", err)
+	}
+	if err := w.WriteItem(map[string]any{"id": "c"}); err != nil {
+		t.Fatalf("WriteItem returned error: %v", err)
+	}
+	if err := w.WriteMeta(map[string]any{"next_page": ""}); err != nil {
+		t.Fatalf("WriteMeta returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`{"id":"a<b"}`,
+		`{"id":"c"}`,
+		`{"_meta":{"count":2,"next_page":""}}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(lines), len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d = %s, want %s", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestListWriterMatchesWriteJSON(t *testing.T) {
+	extra := map[string]any{"folder": "inbox", "path": "/a<b", "items": "ignored"}
+	items := []map[string]any{{"id": "1", "subject": "x&y"}, {"id": "2"}}
+
+	var got bytes.Buffer
+	w := NewListWriter(&got, extra)
+	for _, it := range items {
+		if err := w.WriteItem(it); err != nil {
+			t.Fatalf("WriteItem returned error: %v", err)
+		}
+	}
+	if err := w.Close("tok"); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	var want bytes.Buffer
+	if err := WriteJSON(&want, map[string]any{"folder": "inbox", "path": "/a<b", "items": items, "next_page": "tok"}); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Fatalf("got %s, want %s", got.String(), want.String())
+	}
+
+	var empty bytes.Buffer
+	if err := NewListWriter(&empty, nil).Close(""); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if empty.String() != "{\"items\":[],\"next_page\":\"\"}\n" {
+		t.Fatalf("unexpected empty document %q", empty.String())
+	}
+}
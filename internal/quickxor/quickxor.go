@@ -0,0 +1,105 @@
+// Package quickxor implements the OneDrive QuickXorHash used in
+// driveItem file.hashes.quickXorHash.
+package quickxor
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+const (
+	// Size is the hash length in bytes.
+	Size       = 20
+	widthBits  = 8 * Size
+	shiftBits  = 11
+	cellCount  = (widthBits-1)/64 + 1
+	lastCellBW = widthBits % 64
+)
+
+type digest struct {
+	data        [cellCount]uint64
+	lengthSoFar uint64
+	shiftSoFar  int
+}
+
+// New returns a hash.Hash computing QuickXorHash.
+func New() hash.Hash {
+	return &digest{}
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	currentShift := d.shiftSoFar
+	cellIndex := currentShift / 64
+	cellOffset := currentShift % 64
+	iterations := len(p)
+	if iterations > widthBits {
+		iterations = widthBits
+	}
+
+	for i := 0; i < iterations; i++ {
+		isLastCell := cellIndex == cellCount-1
+		bitsInCell := 64
+		if isLastCell {
+			bitsInCell = lastCellBW
+		}
+
+		if cellOffset <= bitsInCell-8 {
+			for j := i; j < len(p); j += widthBits {
+				d.data[cellIndex] ^= uint64(p[j]) << cellOffset
+			}
+		} else {
+			index2 := cellIndex + 1
+			if isLastCell {
+				index2 = 0
+			}
+			low := bitsInCell - cellOffset
+			var xored byte
+			for j := i; j < len(p); j += widthBits {
+				xored ^= p[j]
+			}
+			d.data[cellIndex] ^= uint64(xored) << cellOffset
+			d.data[index2] ^= uint64(xored) >> low
+		}
+
+		cellOffset += shiftBits
+		for cellOffset >= bitsInCell {
+			if isLastCell {
+				cellIndex = 0
+			} else {
+				cellIndex++
+			}
+			cellOffset -= bitsInCell
+		}
+	}
+
+	d.shiftSoFar = (d.shiftSoFar + shiftBits*(len(p)%widthBits)) % widthBits
+	d.lengthSoFar += uint64(len(p))
+	return len(p), nil
+}
+
+func (d *digest) Sum(b []byte) []byte {
+	var out [Size]byte
+	var cell [8]byte
+	for i := 0; i < cellCount; i++ {
+		binary.LittleEndian.PutUint64(cell[:], d.data[i])
+		copy(out[i*8:], cell[:])
+	}
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], d.lengthSoFar)
+	for i := 0; i < 8; i++ {
+		out[Size-8+i] ^= length[i]
+	}
+	return append(b, out[:]...)
+}
+
+func (d *digest) Reset() {
+	*d = digest{}
+}
+
+func (d *digest) Size() int {
+	return Size
+}
+
+func (d *digest) BlockSize() int {
+	return 64
+}
@@ -0,0 +1,47 @@
+package quickxor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestQuickXorKnownValues(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{in: nil, want: "AAAAAAAAAAAAAAAAAAAAAAAAAAA="},
+		{in: []byte("J"), want: "SgAAAAAAAAAAAAAAAQAAAAAAAAA="},
+	}
+	for _, tt := range tests {
+		h := New()
+		_, _ = h.Write(tt.in)
+		got := base64.StdEncoding.EncodeToString(h.Sum(nil))
+		if got != tt.want {
+			t.Fatalf("quickxor(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuickXorStreamingMatchesSingleWrite(t *testing.T) {
+	data := bytes.Repeat([]byte("mocli quickxor streaming check "), 500)
+
+	whole := New()
+	_, _ = whole.Write(data)
+	want := whole.Sum(nil)
+
+	for _, step := range []int{1, 7, 160, 161, 4096} {
+		h := New()
+		for i := 0; i < len(data); i += step {
+			end := i + step
+			if end > len(data) {
+				end = len(data)
+			}
+			_, _ = h.Write(data[i:end])
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want) {
+			t.Fatalf("chunk size %d: got %x, want %x", step, got, want)
+		}
+	}
+}
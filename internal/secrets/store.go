@@ -45,6 +45,25 @@ type Token struct {
 	UpdatedAt    string `json:"updated_at,omitempty"`
 }
 
+// AccessToken is a cached short-lived Graph access token.
+type AccessToken struct {
+	AccessToken string `json:"access_token"`
+	Scope       string `json:"scope,omitempty"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+// ValidFor reports whether the token is still usable for at least margin.
+func (t AccessToken) ValidFor(now time.Time, margin time.Duration) bool {
+	if strings.TrimSpace(t.AccessToken) == "" {
+		return false
+	}
+	exp, err := time.Parse(time.RFC3339, t.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return now.Add(margin).Before(exp)
+}
+
 type Store struct {
 	backend rawBackend
 }
@@ -118,6 +137,18 @@ func TokenKey(client, email string) string {
 	return "token:" + client + ":" + email
 }
 
+func AccessTokenKey(client, email string) string {
+	client = normalizeClient(client)
+	email = strings.ToLower(strings.TrimSpace(email))
+	return "access:" + client + ":" + email
+}
+
+// LockToken serializes refresh-token rotation for one account across
+// processes. Callers re-read the token after acquiring the lock.
+func LockToken(client, email string) (*config.FileLock, error) {
+	return config.Lock(TokenKey(client, email))
+}
+
 func (s *Store) PutToken(client, email string, token Token) error {
 	if s == nil || s.backend == nil {
 		return errors.New("store is not initialized")
@@ -164,6 +195,45 @@ func (s *Store) DeleteToken(client, email string) error {
 	return nil
 }
 
+func (s *Store) PutAccessToken(client, email string, token AccessToken) error {
+	if s == nil || s.backend == nil {
+		return errors.New("store is not initialized")
+	}
+	if strings.TrimSpace(token.AccessToken) == "" {
+		return errors.New("missing access token")
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("marshal access token: %w", err)
+	}
+	return s.backend.Put(AccessTokenKey(client, email), data)
+}
+
+func (s *Store) GetAccessToken(client, email string) (AccessToken, error) {
+	if s == nil || s.backend == nil {
+		return AccessToken{}, errors.New("store is not initialized")
+	}
+	data, err := s.backend.Get(AccessTokenKey(client, email))
+	if err != nil {
+		return AccessToken{}, err
+	}
+	var tok AccessToken
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return AccessToken{}, fmt.Errorf("parse access token: %w", err)
+	}
+	return tok, nil
+}
+
+func (s *Store) DeleteAccessToken(client, email string) error {
+	if s == nil || s.backend == nil {
+		return errors.New("store is not initialized")
+	}
+	if err := s.backend.Delete(AccessTokenKey(client, email)); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
 func normalizeClient(v string) string {
 	v = strings.TrimSpace(v)
 	if v == "" {
@@ -270,7 +340,7 @@ func (b *fileBackend) Put(key string, value []byte) error {
 	if err != nil {
 		return fmt.Errorf("marshal envelope: %w", err)
 	}
-	if err := os.WriteFile(b.pathForKey(key), data, 0o600); err != nil {
+	if err := config.WriteFileAtomic(b.pathForKey(key), data, 0o600); err != nil {
 		return fmt.Errorf("write encrypted token: %w", err)
 	}
 	return nil
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/svaruag/mocli/internal/config"
 )
@@ -72,6 +73,47 @@ func TestFileStoreTokenRoundTrip(t *testing.T) {
 	}
 }
 
+func TestFileStoreAccessTokenRoundTrip(t *testing.T) {
+	t.Setenv("MO_CONFIG_DIR", t.TempDir())
+
+	lookup := func(key string) (string, bool) {
+		if key == "MO_KEYRING_PASSWORD" {
+			return "test-password", true
+		}
+		return "", false
+	}
+	store, _, err := OpenStore(lookup, config.AppConfig{KeyringBackend: "file"})
+	if err != nil {
+		t.Fatalf("OpenStore returned error: %v", err)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	in := AccessToken{AccessToken: "access-abc", ExpiresAt: now.Add(time.Hour).Format(time.RFC3339)}
+	if err := store.PutAccessToken("default", "User@Example.com", in); err != nil {
+		t.Fatalf("PutAccessToken returned error: %v", err)
+	}
+	got, err := store.GetAccessToken("default", "user@example.com")
+	if err != nil {
+		t.Fatalf("GetAccessToken returned error: %v", err)
+	}
+	if got != in {
+		t.Fatalf("unexpected access token %+v", got)
+	}
+	if !got.ValidFor(now, 5*time.Minute) {
+		t.Fatalf("expected token valid an hour before expiry")
+	}
+	if got.ValidFor(now.Add(56*time.Minute), 5*time.Minute) {
+		t.Fatalf("expected token stale inside the margin")
+	}
+
+	if err := store.DeleteAccessToken("default", "user@example.com"); err != nil {
+		t.Fatalf("DeleteAccessToken returned error: %v", err)
+	}
+	if _, err := store.GetAccessToken("default", "user@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
 func TestOpenStoreFileBackendRequiresPassword(t *testing.T) {
 	t.Setenv("MO_CONFIG_DIR", t.TempDir())
 